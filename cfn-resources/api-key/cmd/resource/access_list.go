@@ -0,0 +1,159 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+
+	atlasSDK "go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+// accessListEntryKey returns the entry in CIDR notation, which is how Atlas reports single addresses too
+func accessListEntryKey(cidrBlock, ipAddress *string) (string, error) {
+	if util.IsStringPresent(cidrBlock) {
+		_, ipNet, err := net.ParseCIDR(*cidrBlock)
+		if err != nil {
+			return "", fmt.Errorf("invalid CidrBlock %s", *cidrBlock)
+		}
+		return ipNet.String(), nil
+	}
+
+	ip := net.ParseIP(util.SafeString(ipAddress))
+	if ip == nil {
+		return "", fmt.Errorf("invalid IpAddress %s", util.SafeString(ipAddress))
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// validateAccessList checks that every entry sets exactly one of CidrBlock and IpAddress, with a valid value
+func validateAccessList(accessList []AccessListEntry) error {
+	seen := map[string]bool{}
+	for i := range accessList {
+		hasCidr := util.IsStringPresent(accessList[i].CidrBlock)
+		hasIP := util.IsStringPresent(accessList[i].IpAddress)
+		if hasCidr == hasIP {
+			return fmt.Errorf("AccessList entry %d must set either CidrBlock or IpAddress", i)
+		}
+		key, err := accessListEntryKey(accessList[i].CidrBlock, accessList[i].IpAddress)
+		if err != nil {
+			return err
+		}
+		if seen[key] {
+			return fmt.Errorf("AccessList contains %s more than once", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// diffAccessList compares the desired entries with the live ones and returns the entries to add and the
+// live entries to remove
+func diffAccessList(desired []AccessListEntry, live []atlasSDK.UserAccessList) (toAdd, toRemove []atlasSDK.UserAccessList) {
+	desiredKeys := map[string]bool{}
+	liveKeys := map[string]bool{}
+	for i := range live {
+		if key, err := accessListEntryKey(live[i].CidrBlock, live[i].IpAddress); err == nil {
+			liveKeys[key] = true
+		}
+	}
+
+	for i := range desired {
+		key, err := accessListEntryKey(desired[i].CidrBlock, desired[i].IpAddress)
+		if err != nil {
+			continue
+		}
+		desiredKeys[key] = true
+		if !liveKeys[key] {
+			toAdd = append(toAdd, atlasSDK.UserAccessList{CidrBlock: desired[i].CidrBlock, IpAddress: desired[i].IpAddress})
+		}
+	}
+
+	for i := range live {
+		key, err := accessListEntryKey(live[i].CidrBlock, live[i].IpAddress)
+		if err == nil && !desiredKeys[key] {
+			toRemove = append(toRemove, live[i])
+		}
+	}
+	return toAdd, toRemove
+}
+
+// reconcileAccessList brings the access list of the key in line with accessList. Entries are added before
+// stale ones are removed, so the key is never left without network restriction in between.
+func reconcileAccessList(atlas *util.MongoDBClient, orgID, apiUserID string, accessList []AccessListEntry) (*http.Response, error) {
+	live, response, err := listAccessListEntries(atlas, orgID, apiUserID)
+	if err != nil {
+		return response, err
+	}
+
+	toAdd, toRemove := diffAccessList(accessList, live)
+	if len(toAdd) > 0 {
+		_, response, err = atlas.AtlasV2.ProgrammaticAPIKeysApi.CreateApiKeyAccessList(context.Background(), orgID, apiUserID, &toAdd).Execute()
+		closeResponse(response)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	for i := range toRemove {
+		entry := toRemove[i].CidrBlock
+		if entry == nil {
+			entry = toRemove[i].IpAddress
+		}
+		_, response, err = atlas.AtlasV2.ProgrammaticAPIKeysApi.DeleteApiKeyAccessListEntry(context.Background(), orgID, apiUserID, *entry).Execute()
+		closeResponse(response)
+		if err != nil && (response == nil || response.StatusCode != http.StatusNotFound) {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// flattenAccessList maps the live entries to the model, keeping the form (CidrBlock or IpAddress) the
+// template used for entries it declares
+func flattenAccessList(declared []AccessListEntry, live []atlasSDK.UserAccessList) []AccessListEntry {
+	declaredByKey := map[string]AccessListEntry{}
+	for i := range declared {
+		if key, err := accessListEntryKey(declared[i].CidrBlock, declared[i].IpAddress); err == nil {
+			declaredByKey[key] = declared[i]
+		}
+	}
+
+	accessList := make([]AccessListEntry, 0, len(live))
+	for i := range live {
+		key, err := accessListEntryKey(live[i].CidrBlock, live[i].IpAddress)
+		if err != nil {
+			continue
+		}
+		if entry, ok := declaredByKey[key]; ok {
+			accessList = append(accessList, entry)
+			continue
+		}
+		accessList = append(accessList, AccessListEntry{CidrBlock: util.Pointer(key)})
+	}
+	sort.Slice(accessList, func(i, j int) bool {
+		return util.SafeString(accessList[i].CidrBlock)+util.SafeString(accessList[i].IpAddress) <
+			util.SafeString(accessList[j].CidrBlock)+util.SafeString(accessList[j].IpAddress)
+	})
+	return accessList
+}
@@ -4,17 +4,21 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	Description        *string             `json:",omitempty"`
-	APIUserId          *string             `json:",omitempty"`
-	AwsSecretName      *string             `json:",omitempty"`
-	OrgId              *string             `json:",omitempty"`
-	Profile            *string             `json:",omitempty"`
-	PublicKey          *string             `json:",omitempty"`
-	PrivateKey         *string             `json:",omitempty"`
-	AwsSecretArn       *string             `json:",omitempty"`
-	Roles              []string            `json:",omitempty"`
-	ProjectAssignments []ProjectAssignment `json:",omitempty"`
-	ListOptions        *ListOptions        `json:",omitempty"`
+	Description                *string             `json:",omitempty"`
+	APIUserId                  *string             `json:",omitempty"`
+	RotatedAPIUserId           *string             `json:",omitempty"`
+	AwsSecretName              *string             `json:",omitempty"`
+	OrgId                      *string             `json:",omitempty"`
+	Profile                    *string             `json:",omitempty"`
+	PublicKey                  *string             `json:",omitempty"`
+	PrivateKey                 *string             `json:",omitempty"`
+	AwsSecretArn               *string             `json:",omitempty"`
+	Roles                      []string            `json:",omitempty"`
+	ProjectAssignments         []ProjectAssignment `json:",omitempty"`
+	ListOptions                *ListOptions        `json:",omitempty"`
+	RotationToken              *string             `json:",omitempty"`
+	RotationGracePeriodMinutes *int                `json:",omitempty"`
+	AccessList                 []AccessListEntry   `json:",omitempty"`
 }
 
 // ProjectAssignment is autogenerated from the json schema
@@ -29,3 +33,9 @@ type ListOptions struct {
 	ItemsPerPage *int  `json:",omitempty"`
 	IncludeCount *bool `json:",omitempty"`
 }
+
+// AccessListEntry is autogenerated from the json schema
+type AccessListEntry struct {
+	CidrBlock *string `json:",omitempty"`
+	IpAddress *string `json:",omitempty"`
+}
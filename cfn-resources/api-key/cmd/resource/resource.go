@@ -41,6 +41,7 @@ var DeleteRequiredFields = []string{constants.OrgID, constants.APIUserID}
 var ListRequiredFields = []string{constants.OrgID}
 
 type APIKeySecret struct {
+	OrgID      string
 	APIUserID  string
 	PublicKey  string
 	PrivateKey string
@@ -58,6 +59,9 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if modelValidation != nil {
 		return *modelValidation, nil
 	}
+	if err := validateAccessList(currentModel.AccessList); err != nil {
+		return progress_events.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
 
 	// Create atlas client
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
@@ -88,13 +92,22 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	// Read response
 	currentModel.APIUserId = apiKeyUserDetails.Id
 
+	// Restrict the key before it is stored anywhere it could be used from
+	if len(currentModel.AccessList) > 0 {
+		response, err = reconcileAccessList(atlas, *currentModel.OrgId, *currentModel.APIUserId, currentModel.AccessList)
+		if err != nil {
+			_ = deleteAPIKey(atlas, *currentModel.OrgId, *currentModel.APIUserId)
+			return handleError(response, constants.CREATE, err)
+		}
+	}
+
 	// Save PrivateKey in AWS SecretManager
-	secret := APIKeySecret{APIUserID: *currentModel.APIUserId, PublicKey: *apiKeyUserDetails.PublicKey, PrivateKey: *apiKeyUserDetails.PrivateKey}
+	secret := APIKeySecret{OrgID: *currentModel.OrgId, APIUserID: *currentModel.APIUserId, PublicKey: *apiKeyUserDetails.PublicKey, PrivateKey: *apiKeyUserDetails.PrivateKey}
 
-	_, _, err = secrets.PutSecret(&req, *currentModel.AwsSecretName, secret, currentModel.Description)
+	_, currentModel.AwsSecretArn, err = secrets.PutSecret(&req, *currentModel.AwsSecretName, secret, currentModel.Description)
 	if err != nil {
 		// Delete the APIKey from Atlas
-		_, _ = Delete(req, prevModel, currentModel)
+		_ = deleteAPIKey(atlas, *currentModel.OrgId, *currentModel.APIUserId)
 		response = &http.Response{StatusCode: http.StatusInternalServerError}
 		return handleError(response, constants.CREATE, err)
 	}
@@ -135,14 +148,27 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		return *peErr, nil
 	}
 
-	apiKeyUserDetails, arn, response, err := getAPIkeyDetails(&req, atlas, currentModel)
+	currentModel.resolveKeyID(&req)
+	apiKeyUserDetails, _, response, err := getAPIkeyDetails(&req, atlas, currentModel)
 
 	defer closeResponse(response)
 	if err != nil {
 		return handleError(response, constants.READ, err)
 	}
-	currentModel.AwsSecretArn = arn
+	declaredAccessList := currentModel.AccessList
+	apiUserID := currentModel.APIUserId
 	currentModel.readAPIKeyDetails(*apiKeyUserDetails)
+	// the resource keeps being identified by the first key after a rotation
+	currentModel.APIUserId = apiUserID
+
+	accessList, response, err := listAccessListEntries(atlas, *currentModel.OrgId, *currentModel.keyID())
+	if err != nil {
+		return handleError(response, constants.READ, err)
+	}
+	currentModel.AccessList = nil
+	if len(accessList) > 0 {
+		currentModel.AccessList = flattenAccessList(declaredAccessList, accessList)
+	}
 	_, _ = logger.Debugf("Read Response: %+v", currentModel)
 
 	return handler.ProgressEvent{
@@ -158,6 +184,9 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if modelValidation != nil {
 		return *modelValidation, nil
 	}
+	if err := validateAccessList(currentModel.AccessList); err != nil {
+		return progress_events.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
 
 	// Create atlas client
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
@@ -168,6 +197,26 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if peErr != nil {
 		return *peErr, nil
 	}
+
+	if prevModel != nil {
+		if currentModel.RotatedAPIUserId == nil {
+			currentModel.RotatedAPIUserId = prevModel.RotatedAPIUserId
+		}
+		if currentModel.AwsSecretArn == nil {
+			currentModel.AwsSecretArn = prevModel.AwsSecretArn
+		}
+	}
+	currentModel.resolveKeyID(&req)
+
+	// Rotation: the replacement key is handed over first, the old key is deleted on a later callback
+	if _, ok := req.CallbackContext[rotationOldAPIUserID]; ok {
+		if pe := completeKeyRotation(atlas, req.CallbackContext, currentModel); pe != nil {
+			return *pe, nil
+		}
+	} else if isRotationRequested(prevModel, currentModel) {
+		return startKeyRotation(&req, atlas, currentModel), nil
+	}
+
 	// Set the roles from model
 	apiKeyInput := atlasSDK.UpdateAtlasOrganizationApiKey{
 		Desc:  currentModel.Description,
@@ -176,7 +225,7 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	updateRequest := atlas.AtlasV2.ProgrammaticAPIKeysApi.UpdateApiKey(
 		context.Background(),
 		*currentModel.OrgId,
-		*currentModel.APIUserId,
+		*currentModel.keyID(),
 		&apiKeyInput,
 	)
 	apiKeyUserDetails, response, err := updateRequest.Execute()
@@ -189,7 +238,7 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if err != nil {
 		return handleError(response, constants.READ, err)
 	}
-	existingModel := Model{APIUserId: currentModel.APIUserId, OrgId: currentModel.OrgId}
+	existingModel := Model{APIUserId: currentModel.keyID(), OrgId: currentModel.OrgId}
 	// Read response
 	existingModel.readAPIKeyDetails(*apiKeyUserDetails)
 
@@ -200,6 +249,14 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return handleError(response, constants.UPDATE, err)
 	}
 
+	// AccessList is only managed when the template declares it
+	if currentModel.AccessList != nil || (prevModel != nil && prevModel.AccessList != nil) {
+		response, err = reconcileAccessList(atlas, *currentModel.OrgId, *currentModel.keyID(), currentModel.AccessList)
+		if err != nil {
+			return handleError(response, constants.UPDATE, err)
+		}
+	}
+
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Update Completed",
@@ -224,10 +281,11 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if peErr != nil {
 		return *peErr, nil
 	}
+	currentModel.resolveKeyID(&req)
 	deleteRequest := atlas.AtlasV2.ProgrammaticAPIKeysApi.DeleteApiKey(
 		context.Background(),
 		*currentModel.OrgId,
-		*currentModel.APIUserId,
+		*currentModel.keyID(),
 	)
 	_, response, err := deleteRequest.Execute()
 	defer closeResponse(response)
@@ -235,6 +293,14 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return handleError(response, constants.DELETE, err)
 	}
 
+	// the key replaced by the last scheduled rotation is only deleted by the next one
+	if previousKeyID := currentModel.previousKeyID(&req); previousKeyID != "" {
+		if err = deleteAPIKey(atlas, *currentModel.OrgId, previousKeyID); err != nil {
+			return progress_events.GetFailedEventByCode(fmt.Sprintf("error deleting rotated API key %s: %s", previousKeyID, err.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+	}
+
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Delete Completed",
@@ -340,7 +406,7 @@ func getAPIkeyDetails(req *handler.Request, atlas *util.MongoDBClient, currentMo
 	apiKeyRequest := atlas.AtlasV2.ProgrammaticAPIKeysApi.GetApiKey(
 		context.Background(),
 		*currentModel.OrgId,
-		*currentModel.APIUserId,
+		*currentModel.keyID(),
 	)
 	apiKeyUserDetails, response, err := apiKeyRequest.Execute()
 
@@ -382,7 +448,7 @@ func updateProjectAssignments(atlasClient *util.MongoDBClient, currentModel *Mod
 
 	// Assignment
 	for i := range newAssignments {
-		result, response, err = updateOrgKeyProjectRoles(newAssignments[i], atlasClient, currentModel.APIUserId)
+		result, response, err = updateOrgKeyProjectRoles(newAssignments[i], atlasClient, currentModel.keyID())
 		if err != nil {
 			break
 		}
@@ -394,7 +460,7 @@ func updateProjectAssignments(atlasClient *util.MongoDBClient, currentModel *Mod
 
 	// Update Project Roles
 	for i := range updateAssignments {
-		result, response, err = updateOrgKeyProjectRoles(updateAssignments[i], atlasClient, currentModel.APIUserId)
+		result, response, err = updateOrgKeyProjectRoles(updateAssignments[i], atlasClient, currentModel.keyID())
 		if err != nil {
 			break
 		}
@@ -406,7 +472,7 @@ func updateProjectAssignments(atlasClient *util.MongoDBClient, currentModel *Mod
 
 	// Remove Assignment
 	for i := range removeAssignments {
-		result, response, err = unAssignProjectFromOrgKey(removeAssignments[i], atlasClient, currentModel.APIUserId)
+		result, response, err = unAssignProjectFromOrgKey(removeAssignments[i], atlasClient, currentModel.keyID())
 		if err != nil {
 			break
 		}
@@ -479,6 +545,15 @@ func areStringArraysEqualIgnoreOrder(arr1, arr2 []string) bool {
 	return true
 }
 
+// keyID returns the ID of the Atlas key behind the resource: the key minted by the last rotation, or else the
+// key created with the resource
+func (model *Model) keyID() *string {
+	if util.IsStringPresent(model.RotatedAPIUserId) {
+		return model.RotatedAPIUserId
+	}
+	return model.APIUserId
+}
+
 func (model *Model) readAPIKeyDetails(apikey atlasSDK.ApiKeyUserDetails) Model {
 	model.APIUserId = apikey.Id
 	model.Description = apikey.Desc
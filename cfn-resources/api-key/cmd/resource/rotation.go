@@ -0,0 +1,341 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	progress_events "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/secrets"
+
+	atlasSDK "go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	rotationOldAPIUserID              = "RotationOldAPIUserId"
+	rotationDeleteAfter               = "RotationDeleteAfter"
+	defaultRotationGracePeriodMinutes = 5
+	maxRotationCallbackDelaySeconds   = 300
+	accessListItemsPerPage            = 500
+)
+
+// isRotationRequested reports whether the RotationToken changed between the previous and the current model
+func isRotationRequested(prevModel, currentModel *Model) bool {
+	if prevModel == nil || !util.IsStringPresent(currentModel.RotationToken) {
+		return false
+	}
+	return !util.AreStringPtrEqual(prevModel.RotationToken, currentModel.RotationToken)
+}
+
+func rotationGracePeriod(model *Model) time.Duration {
+	if model.RotationGracePeriodMinutes == nil {
+		return defaultRotationGracePeriodMinutes * time.Minute
+	}
+	return time.Duration(*model.RotationGracePeriodMinutes) * time.Minute
+}
+
+func rotationCallbackDelay(remaining time.Duration) int64 {
+	seconds := int64(remaining.Seconds()) + 1
+	if seconds > maxRotationCallbackDelaySeconds {
+		return maxRotationCallbackDelaySeconds
+	}
+	return seconds
+}
+
+// MintReplacementKey creates a new organization API key with the same description, organization roles,
+// project assignments and access list as the key identified by apiUserID. When any of these cannot be
+// copied the new key is deleted again, so a partially configured key is never handed over.
+func MintReplacementKey(atlas *util.MongoDBClient, orgID, apiUserID string) (*atlasSDK.ApiKeyUserDetails, *http.Response, error) {
+	ctx := context.Background()
+	oldKey, response, err := atlas.AtlasV2.ProgrammaticAPIKeysApi.GetApiKey(ctx, orgID, apiUserID).Execute()
+	closeResponse(response)
+	if err != nil {
+		return nil, response, err
+	}
+
+	var oldModel Model
+	oldModel.readAPIKeyDetails(*oldKey)
+
+	newKey, response, err := atlas.AtlasV2.ProgrammaticAPIKeysApi.CreateApiKey(ctx, orgID, &atlasSDK.CreateAtlasOrganizationApiKey{
+		Desc:  util.SafeString(oldKey.Desc),
+		Roles: oldModel.Roles,
+	}).Execute()
+	closeResponse(response)
+	if err != nil {
+		return nil, response, err
+	}
+
+	response, err = copyKeyGrants(atlas, orgID, apiUserID, *newKey.Id, oldModel.ProjectAssignments)
+	if err != nil {
+		_ = deleteAPIKey(atlas, orgID, *newKey.Id)
+		return nil, response, err
+	}
+	return newKey, response, nil
+}
+
+func copyKeyGrants(atlas *util.MongoDBClient, orgID, fromAPIUserID, toAPIUserID string, projectAssignments []ProjectAssignment) (*http.Response, error) {
+	for i := range projectAssignments {
+		_, response, err := updateOrgKeyProjectRoles(projectAssignments[i], atlas, &toAPIUserID)
+		closeResponse(response)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	entries, response, err := listAccessListEntries(atlas, orgID, fromAPIUserID)
+	if err != nil || len(entries) == 0 {
+		return response, err
+	}
+	_, response, err = atlas.AtlasV2.ProgrammaticAPIKeysApi.CreateApiKeyAccessList(context.Background(), orgID, toAPIUserID, &entries).Execute()
+	closeResponse(response)
+	return response, err
+}
+
+// listAccessListEntries returns every access list entry of the key, following the pages of the API
+func listAccessListEntries(atlas *util.MongoDBClient, orgID, apiUserID string) ([]atlasSDK.UserAccessList, *http.Response, error) {
+	return util.ListAll(accessListItemsPerPage, func(pageNum int) ([]atlasSDK.UserAccessList, *http.Response, error) {
+		page, response, err := atlas.AtlasV2.ProgrammaticAPIKeysApi.ListApiKeyAccessListsEntries(context.Background(), orgID, apiUserID).
+			PageNum(pageNum).ItemsPerPage(accessListItemsPerPage).Execute()
+		closeResponse(response)
+		if err != nil {
+			return nil, response, err
+		}
+		entries := make([]atlasSDK.UserAccessList, len(page.Results))
+		for i := range page.Results {
+			// the API returns both cidrBlock and ipAddress for single addresses but accepts only one of them
+			entries[i] = atlasSDK.UserAccessList{CidrBlock: page.Results[i].CidrBlock}
+			if entries[i].CidrBlock == nil {
+				entries[i].IpAddress = page.Results[i].IpAddress
+			}
+		}
+		return entries, response, nil
+	})
+}
+
+// deleteAPIKey removes the key, ignoring keys that no longer exist
+func deleteAPIKey(atlas *util.MongoDBClient, orgID, apiUserID string) error {
+	_, response, err := atlas.AtlasV2.ProgrammaticAPIKeysApi.DeleteApiKey(context.Background(), orgID, apiUserID).Execute()
+	closeResponse(response)
+	if err != nil && (response == nil || response.StatusCode != http.StatusNotFound) {
+		_, _ = logger.Warnf("error deleting API key %s: %s", apiUserID, err.Error())
+		return err
+	}
+	return nil
+}
+
+// startKeyRotation mints the replacement key, hands it over through the secret and schedules the removal
+// of the old key once the grace period is over
+func startKeyRotation(req *handler.Request, atlas *util.MongoDBClient, currentModel *Model) handler.ProgressEvent {
+	if !util.IsStringPresent(currentModel.AwsSecretName) {
+		return progress_events.GetFailedEventByCode("AwsSecretName is required to rotate the API key", cloudformation.HandlerErrorCodeInvalidRequest)
+	}
+
+	orgID := *currentModel.OrgId
+	oldAPIUserID := *currentModel.keyID()
+	newKey, response, err := MintReplacementKey(atlas, orgID, oldAPIUserID)
+	if err != nil {
+		if response == nil {
+			return progress_events.GetFailedEventByCode(fmt.Sprintf("error creating replacement API key: %s", err.Error()), cloudformation.HandlerErrorCodeServiceInternalError)
+		}
+		return progress_events.GetFailedEventByResponse(fmt.Sprintf("error creating replacement API key: %s", err.Error()), response)
+	}
+
+	secret := APIKeySecret{OrgID: orgID, APIUserID: *newKey.Id, PublicKey: *newKey.PublicKey, PrivateKey: *newKey.PrivateKey}
+	if err = handOverSecret(req, *currentModel.AwsSecretName, secret, nil); err != nil {
+		_ = deleteAPIKey(atlas, orgID, *newKey.Id)
+		return progress_events.GetFailedEventByCode(fmt.Sprintf("error storing rotated API key: %s", err.Error()), cloudformation.HandlerErrorCodeServiceInternalError)
+	}
+
+	currentModel.RotatedAPIUserId = newKey.Id
+	currentModel.PublicKey = newKey.PublicKey
+	deleteAfter := time.Now().Add(rotationGracePeriod(currentModel))
+	_, _ = logger.Debugf("API key %s rotated to %s, old key deleted after %s", oldAPIUserID, *newKey.Id, deleteAfter)
+
+	return handler.ProgressEvent{
+		OperationStatus:      handler.InProgress,
+		Message:              "Waiting for the rotation grace period",
+		ResourceModel:        currentModel,
+		CallbackDelaySeconds: rotationCallbackDelay(time.Until(deleteAfter)),
+		CallbackContext: map[string]interface{}{
+			rotationOldAPIUserID: oldAPIUserID,
+			rotationDeleteAfter:  deleteAfter.Unix(),
+		},
+	}
+}
+
+// completeKeyRotation deletes the old key once the grace period is over. It returns nil when the rotation
+// is finished, or the event to return while the handler still has to wait.
+func completeKeyRotation(atlas *util.MongoDBClient, callbackContext map[string]interface{}, currentModel *Model) *handler.ProgressEvent {
+	oldAPIUserID, ok := callbackContext[rotationOldAPIUserID].(string)
+	if !ok {
+		return nil
+	}
+	deleteAfter, _ := callbackContext[rotationDeleteAfter].(float64)
+	if remaining := time.Until(time.Unix(int64(deleteAfter), 0)); remaining > 0 {
+		return &handler.ProgressEvent{
+			OperationStatus:      handler.InProgress,
+			Message:              "Waiting for the rotation grace period",
+			ResourceModel:        currentModel,
+			CallbackDelaySeconds: rotationCallbackDelay(remaining),
+			CallbackContext:      callbackContext,
+		}
+	}
+
+	if err := deleteAPIKey(atlas, *currentModel.OrgId, oldAPIUserID); err != nil {
+		pe := progress_events.GetFailedEventByCode(fmt.Sprintf("error deleting rotated API key %s: %s", oldAPIUserID, err.Error()), cloudformation.HandlerErrorCodeServiceInternalError)
+		return &pe
+	}
+	return nil
+}
+
+// handOverSecret writes the secret as a new AWSPENDING version and promotes it to AWSCURRENT, leaving the
+// previous key readable as AWSPREVIOUS
+func handOverSecret(req *handler.Request, secretName string, secret APIKeySecret, clientRequestToken *string) error {
+	versionID, err := secrets.PutPendingSecret(req, secretName, secret, clientRequestToken)
+	if err != nil {
+		return err
+	}
+	return secrets.PromoteSecretVersion(req, secretName, *versionID)
+}
+
+// getSecretVersion reads a version of the API key secret. The secrets written before the organization was stored
+// in them belong to orgID.
+func getSecretVersion(req *handler.Request, secretID string, versionID, stage *string, orgID string) (*APIKeySecret, error) {
+	secretString, _, err := secrets.GetSecretVersion(req, secretID, versionID, stage)
+	if err != nil {
+		return nil, err
+	}
+	var secret APIKeySecret
+	if err = json.Unmarshal([]byte(aws.StringValue(secretString)), &secret); err != nil {
+		return nil, err
+	}
+	if secret.OrgID == "" {
+		secret.OrgID = orgID
+	}
+	if secret.OrgID == "" || secret.APIUserID == "" {
+		return nil, errors.New("secret does not contain an organization API key")
+	}
+	return &secret, nil
+}
+
+// CreatePendingSecret implements the createSecret step of a Secrets Manager rotation: it mints a
+// replacement for the key stored as AWSCURRENT and stores it as the AWSPENDING version identified by token.
+// orgID is the organization of the secrets that don't store it.
+func CreatePendingSecret(req *handler.Request, atlas *util.MongoDBClient, secretID, token, orgID string) error {
+	if _, err := getSecretVersion(req, secretID, &token, aws.String(secrets.StagePending), orgID); err == nil {
+		return nil
+	}
+
+	current, err := getSecretVersion(req, secretID, nil, aws.String(secrets.StageCurrent), orgID)
+	if err != nil {
+		return err
+	}
+	newKey, _, err := MintReplacementKey(atlas, current.OrgID, current.APIUserID)
+	if err != nil {
+		return err
+	}
+
+	secret := APIKeySecret{OrgID: current.OrgID, APIUserID: *newKey.Id, PublicKey: *newKey.PublicKey, PrivateKey: *newKey.PrivateKey}
+	if _, err = secrets.PutPendingSecret(req, secretID, secret, &token); err != nil {
+		_ = deleteAPIKey(atlas, current.OrgID, *newKey.Id)
+		return err
+	}
+	return nil
+}
+
+// TestPendingSecret implements the testSecret step: the pending key must authenticate against Atlas
+func TestPendingSecret(req *handler.Request, atlas *util.MongoDBClient, secretID, token, orgID string) error {
+	pending, err := getSecretVersion(req, secretID, &token, aws.String(secrets.StagePending), orgID)
+	if err != nil {
+		return err
+	}
+	client, err := util.NewAtlasV2ClientWithKeys(pending.PublicKey, pending.PrivateKey, atlas.Config.BaseURL)
+	if err != nil {
+		return err
+	}
+	_, response, err := client.AtlasV2.ProgrammaticAPIKeysApi.GetApiKey(context.Background(), pending.OrgID, pending.APIUserID).Execute()
+	closeResponse(response)
+	return err
+}
+
+// FinishPendingSecret implements the finishSecret step: the key replaced by the previous rotation has been
+// kept as AWSPREVIOUS for a whole rotation interval and is deleted before the pending version is promoted.
+func FinishPendingSecret(req *handler.Request, atlas *util.MongoDBClient, secretID, token, orgID string) error {
+	pending, err := getSecretVersion(req, secretID, &token, aws.String(secrets.StagePending), orgID)
+	if err != nil {
+		return err
+	}
+	previous, err := getSecretVersion(req, secretID, nil, aws.String(secrets.StagePrevious), orgID)
+	if err == nil && previous.APIUserID != pending.APIUserID {
+		if err = deleteAPIKey(atlas, previous.OrgID, previous.APIUserID); err != nil {
+			return err
+		}
+	}
+	return secrets.PromoteSecretVersion(req, secretID, token)
+}
+
+// resolveKeyID points RotatedAPIUserId at the key stored as AWSCURRENT in the secret of the resource. The rotation
+// function replaces the key without updating the stack, so the key known to the stack may already be deleted.
+// The stack's key is kept when the secret is unknown or can't be read.
+func (model *Model) resolveKeyID(req *handler.Request) {
+	secretID := model.secretID()
+	if secretID == "" {
+		return
+	}
+	current, err := getSecretVersion(req, secretID, nil, aws.String(secrets.StageCurrent), *model.OrgId)
+	if err != nil {
+		_, _ = logger.Warnf("error reading API key secret %s, using the key of the stack: %s", secretID, err.Error())
+		return
+	}
+	if current.OrgID != *model.OrgId || current.APIUserID == util.SafeString(model.keyID()) {
+		return
+	}
+	if current.APIUserID == util.SafeString(model.APIUserId) {
+		model.RotatedAPIUserId = nil
+		return
+	}
+	model.RotatedAPIUserId = aws.String(current.APIUserID)
+}
+
+// previousKeyID returns the key that the rotation function keeps as AWSPREVIOUS until the next rotation, if it is
+// not the current key of the resource
+func (model *Model) previousKeyID(req *handler.Request) string {
+	secretID := model.secretID()
+	if secretID == "" {
+		return ""
+	}
+	previous, err := getSecretVersion(req, secretID, nil, aws.String(secrets.StagePrevious), *model.OrgId)
+	if err != nil || previous.OrgID != *model.OrgId || previous.APIUserID == util.SafeString(model.keyID()) {
+		return ""
+	}
+	return previous.APIUserID
+}
+
+func (model *Model) secretID() string {
+	if util.IsStringPresent(model.AwsSecretArn) {
+		return *model.AwsSecretArn
+	}
+	return util.SafeString(model.AwsSecretName)
+}
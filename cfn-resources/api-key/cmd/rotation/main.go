@@ -0,0 +1,76 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Secrets Manager rotation function for the secrets written by MongoDB::Atlas::APIKey.
+// The Atlas profile used to manage the keys is read from the ATLAS_PROFILE environment variable, and the organization
+// of secrets written without one from ATLAS_ORG_ID.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/api-key/cmd/resource"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/profile"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+)
+
+// RotationEvent is the payload Secrets Manager sends for each rotation step
+type RotationEvent struct {
+	SecretID           string `json:"SecretId"`
+	ClientRequestToken string `json:"ClientRequestToken"`
+	Step               string `json:"Step"`
+}
+
+func handleRotation(ctx context.Context, event RotationEvent) error {
+	util.SetupLogger("mongodb-atlas-api-key-rotation")
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+	req := handler.Request{Session: sess}
+
+	profileName := os.Getenv("ATLAS_PROFILE")
+	if profileName == "" {
+		profileName = profile.DefaultProfile
+	}
+	atlas, peErr := util.NewAtlasV2OnlyClient(&req, &profileName, true)
+	if peErr != nil {
+		return fmt.Errorf("error creating Atlas client: %s", peErr.Message)
+	}
+
+	orgID := os.Getenv("ATLAS_ORG_ID")
+	switch event.Step {
+	case "createSecret":
+		return resource.CreatePendingSecret(&req, atlas, event.SecretID, event.ClientRequestToken, orgID)
+	case "setSecret":
+		// the key is created in Atlas during createSecret, nothing has to be set
+		return nil
+	case "testSecret":
+		return resource.TestPendingSecret(&req, atlas, event.SecretID, event.ClientRequestToken, orgID)
+	case "finishSecret":
+		return resource.FinishPendingSecret(&req, atlas, event.SecretID, event.ClientRequestToken, orgID)
+	default:
+		return fmt.Errorf("unknown rotation step %q", event.Step)
+	}
+}
+
+func main() {
+	lambda.Start(handleRotation)
+}
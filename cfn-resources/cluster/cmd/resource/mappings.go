@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
@@ -26,6 +27,9 @@ import (
 	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
 
+// mongosProcessType is the process type of the private endpoint connection strings of sharded clusters
+const mongosProcessType = "MONGOS"
+
 func mapClusterToModel(model *Model, cluster *admin.AdvancedClusterDescription) {
 	model.Id = cluster.Id
 	model.ProjectId = cluster.GroupId
@@ -370,6 +374,7 @@ type privateEndpointConnectionStrings struct {
 	PrivateEndpoints                  []string
 	PrivateEndpointsSrv               []string
 	SRVShardOptimizedConnectionString []string
+	Connections                       []PrivateEndpointConnection
 }
 
 func flattenConnectionStrings(clusterConnStrings *admin.ClusterConnectionStrings) (connStrings *ConnectionStrings) {
@@ -383,6 +388,7 @@ func flattenConnectionStrings(clusterConnStrings *admin.ClusterConnectionStrings
 			PrivateEndpoints:                  privateEndpoints.PrivateEndpoints,
 			PrivateEndpointsSrv:               privateEndpoints.PrivateEndpointsSrv,
 			SRVShardOptimizedConnectionString: privateEndpoints.SRVShardOptimizedConnectionString,
+			PrivateEndpointConnections:        privateEndpoints.Connections,
 		}
 	}
 	return
@@ -393,6 +399,7 @@ func flattenPrivateEndpoint(pes []admin.ClusterDescriptionConnectionStringsPriva
 		PrivateEndpoints:                  make([]string, 0),
 		PrivateEndpointsSrv:               make([]string, 0),
 		SRVShardOptimizedConnectionString: make([]string, 0),
+		Connections:                       make([]PrivateEndpointConnection, 0, len(pes)),
 	}
 
 	for _, pe := range pes {
@@ -407,10 +414,51 @@ func flattenPrivateEndpoint(pes []admin.ClusterDescriptionConnectionStringsPriva
 		if util.IsStringPresent(pe.SrvShardOptimizedConnectionString) {
 			privateEndpoints.SRVShardOptimizedConnectionString = append(privateEndpoints.SRVShardOptimizedConnectionString, *pe.SrvShardOptimizedConnectionString)
 		}
+
+		privateEndpoints.Connections = append(privateEndpoints.Connections, flattenPrivateEndpointConnection(pe))
 	}
 	return privateEndpoints
 }
 
+func flattenPrivateEndpointConnection(pe admin.ClusterDescriptionConnectionStringsPrivateEndpoint) PrivateEndpointConnection {
+	connection := PrivateEndpointConnection{
+		Type:                              pe.Type,
+		ConnectionString:                  pe.ConnectionString,
+		SrvConnectionString:               pe.SrvConnectionString,
+		SrvShardOptimizedConnectionString: pe.SrvShardOptimizedConnectionString,
+		Endpoints:                         make([]PrivateEndpointEndpoint, len(pe.Endpoints)),
+	}
+	for i := range pe.Endpoints {
+		connection.Endpoints[i] = PrivateEndpointEndpoint{
+			EndpointId:   pe.Endpoints[i].EndpointId,
+			ProviderName: pe.Endpoints[i].ProviderName,
+			Region:       pe.Endpoints[i].Region,
+		}
+	}
+	if util.SafeString(pe.Type) == mongosProcessType {
+		connection.MongosHosts = connectionStringHosts(util.SafeString(pe.ConnectionString))
+	}
+	return connection
+}
+
+// connectionStringHosts returns the host:port list of a mongodb:// connection string
+func connectionStringHosts(connectionString string) []string {
+	hosts, found := strings.CutPrefix(connectionString, "mongodb://")
+	if !found {
+		return nil
+	}
+	if i := strings.IndexAny(hosts, "/?"); i >= 0 {
+		hosts = hosts[:i]
+	}
+	if i := strings.LastIndex(hosts, "@"); i >= 0 {
+		hosts = hosts[i+1:]
+	}
+	if hosts == "" {
+		return nil
+	}
+	return strings.Split(hosts, ",")
+}
+
 func flattenProcessArgs(p *admin.ClusterDescriptionProcessArgs) *ProcessArgs {
 	return &ProcessArgs{
 		DefaultReadConcern:               p.DefaultReadConcern,
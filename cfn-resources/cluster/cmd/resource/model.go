@@ -52,13 +52,31 @@ type BiConnector struct {
 
 // ConnectionStrings is autogenerated from the json schema
 type ConnectionStrings struct {
-	Standard                          *string  `json:",omitempty"`
-	StandardSrv                       *string  `json:",omitempty"`
-	Private                           *string  `json:",omitempty"`
-	PrivateSrv                        *string  `json:",omitempty"`
-	PrivateEndpoints                  []string `json:",omitempty"`
-	PrivateEndpointsSrv               []string `json:",omitempty"`
-	SRVShardOptimizedConnectionString []string `json:",omitempty"`
+	Standard                          *string                     `json:",omitempty"`
+	StandardSrv                       *string                     `json:",omitempty"`
+	Private                           *string                     `json:",omitempty"`
+	PrivateSrv                        *string                     `json:",omitempty"`
+	PrivateEndpoints                  []string                    `json:",omitempty"`
+	PrivateEndpointsSrv               []string                    `json:",omitempty"`
+	SRVShardOptimizedConnectionString []string                    `json:",omitempty"`
+	PrivateEndpointConnections        []PrivateEndpointConnection `json:",omitempty"`
+}
+
+// PrivateEndpointConnection is autogenerated from the json schema
+type PrivateEndpointConnection struct {
+	Type                              *string                   `json:",omitempty"`
+	ConnectionString                  *string                   `json:",omitempty"`
+	SrvConnectionString               *string                   `json:",omitempty"`
+	SrvShardOptimizedConnectionString *string                   `json:",omitempty"`
+	MongosHosts                       []string                  `json:",omitempty"`
+	Endpoints                         []PrivateEndpointEndpoint `json:",omitempty"`
+}
+
+// PrivateEndpointEndpoint is autogenerated from the json schema
+type PrivateEndpointEndpoint struct {
+	EndpointId   *string `json:",omitempty"`
+	ProviderName *string `json:",omitempty"`
+	Region       *string `json:",omitempty"`
 }
 
 // Labels is autogenerated from the json schema
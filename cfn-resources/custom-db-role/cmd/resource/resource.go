@@ -18,11 +18,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
 	progress_events "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
 	"go.mongodb.org/atlas-sdk/v20231001001/admin"
@@ -38,6 +40,12 @@ var UpdateRequiredFields = []string{constants.ProjectID, constants.RoleName}
 var DeleteRequiredFields = []string{constants.ProjectID, constants.RoleName}
 var ListRequiredFields = []string{constants.ProjectID}
 
+const (
+	inheritedRolesRetries    = "InheritedRolesRetries"
+	inheritedRolesMaxRetries = 10
+	inheritedRolesRetryDelay = 15
+)
+
 // Create handles the Create event from the Cloudformation service.
 func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
@@ -53,6 +61,10 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
+	if pe := validateRoleDependencies(req, client, currentModel); pe != nil {
+		return *pe, nil
+	}
+
 	atlasCustomDBRole := currentModel.ToCustomDBRole()
 	customDBRole, response, err := client.AtlasV2.CustomDatabaseRolesApi.CreateCustomDatabaseRole(context.Background(), *currentModel.ProjectId, atlasCustomDBRole).Execute()
 	if err != nil {
@@ -115,19 +127,37 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	var actions []admin.DatabasePrivilegeAction
-	for _, a := range currentModel.Actions {
-		actions = append(actions, a.toAtlasAction())
+	if pe := validateRoleDependencies(req, client, currentModel); pe != nil {
+		return *pe, nil
 	}
 
-	var inheritedRoles []admin.DatabaseInheritedRole
-	for _, ir := range currentModel.InheritedRoles {
-		inheritedRoles = append(inheritedRoles, ir.toAtlasInheritedRole())
+	currentRole, response, err := client.AtlasV2.CustomDatabaseRolesApi.GetCustomDatabaseRole(context.Background(), *currentModel.ProjectId, *currentModel.RoleName).Execute()
+	if err != nil {
+		return progress_events.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
+			response), nil
 	}
 
-	inputCustomDBRole := admin.UpdateCustomDBRole{
-		Actions:        actions,
-		InheritedRoles: inheritedRoles,
+	desiredRole := currentModel.ToCustomDBRole()
+
+	// only send the parts of the role that changed, Atlas replaces each list it receives as a whole
+	inputCustomDBRole := admin.UpdateCustomDBRole{}
+	addedPrivileges, removedPrivileges := diffPrivileges(currentRole.Actions, desiredRole.Actions)
+	actionsChanged := len(addedPrivileges) > 0 || len(removedPrivileges) > 0
+	if actionsChanged {
+		_, _ = logger.Debugf("Role %s privileges added: %v removed: %v", *currentModel.RoleName, addedPrivileges, removedPrivileges)
+		inputCustomDBRole.Actions = desiredRole.Actions
+	}
+	inheritedRolesChanged := !areInheritedRolesEqual(currentRole.InheritedRoles, desiredRole.InheritedRoles)
+	if inheritedRolesChanged {
+		inputCustomDBRole.InheritedRoles = desiredRole.InheritedRoles
+	}
+
+	if !actionsChanged && !inheritedRolesChanged {
+		currentModel.completeByAtlasRole(*currentRole)
+		return handler.ProgressEvent{
+			OperationStatus: handler.Success,
+			Message:         "Update successful",
+			ResourceModel:   currentModel}, nil
 	}
 
 	atlasCustomDdRole, response, err := client.AtlasV2.CustomDatabaseRolesApi.UpdateCustomDatabaseRole(context.Background(), *currentModel.ProjectId,
@@ -306,3 +336,50 @@ func atlasInheritedRoleToModel(inheritedRole admin.DatabaseInheritedRole) Inheri
 		Role: &inheritedRole.Role,
 	}
 }
+
+// validateRoleDependencies checks the actions and the inherited roles of the model against the custom roles of the project.
+// An inherited custom role that does not exist yet may be created by another resource of the same stack,
+// so CloudFormation is asked to retry a few times before failing.
+func validateRoleDependencies(req handler.Request, client *util.MongoDBClient, currentModel *Model) *handler.ProgressEvent {
+	if err := validateActions(currentModel.Actions); err != nil {
+		pe := progress_events.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+
+	if len(currentModel.InheritedRoles) == 0 {
+		return nil
+	}
+
+	customRoles, response, err := client.AtlasV2.CustomDatabaseRolesApi.ListCustomDatabaseRoles(context.Background(), *currentModel.ProjectId).Execute()
+	if err != nil {
+		pe := progress_events.GetFailedEventByResponse(fmt.Sprintf("Error listing custom roles : %s", err.Error()), response)
+		return &pe
+	}
+	graph := customRoleGraph(customRoles)
+
+	if cycle := findInheritanceCycle(graph, *currentModel.RoleName, currentModel.InheritedRoles); cycle != nil {
+		pe := progress_events.GetFailedEventByCode(fmt.Sprintf("Inherited roles create a cycle: %s", strings.Join(cycle, " -> ")),
+			cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+
+	missing := missingInheritedRoles(currentModel.InheritedRoles, graph)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	retries := 0
+	if r, ok := req.CallbackContext[inheritedRolesRetries].(float64); ok {
+		retries = int(r)
+	}
+	if retries >= inheritedRolesMaxRetries {
+		pe := progress_events.GetFailedEventByCode(fmt.Sprintf("Inherited custom roles %s do not exist in project %s",
+			strings.Join(missing, ", "), *currentModel.ProjectId), cloudformation.HandlerErrorCodeNotFound)
+		return &pe
+	}
+
+	_, _ = logger.Debugf("Waiting for inherited custom roles %v, attempt %d", missing, retries+1)
+	pe := progress_events.GetInProgressProgressEvent(fmt.Sprintf("Waiting for inherited custom roles %s", strings.Join(missing, ", ")),
+		map[string]interface{}{inheritedRolesRetries: retries + 1}, currentModel, inheritedRolesRetryDelay)
+	return &pe
+}
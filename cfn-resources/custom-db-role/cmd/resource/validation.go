@@ -0,0 +1,182 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+// privilegeActions is the Atlas catalogue of privilege actions that a custom role can grant
+var privilegeActions = map[string]bool{
+	"FIND": true, "INSERT": true, "REMOVE": true, "UPDATE": true, "BYPASS_DOCUMENT_VALIDATION": true, "USE_UUID": true,
+	"KILL_OP": true, "CREATE_COLLECTION": true, "CREATE_INDEX": true, "DROP_COLLECTION": true, "ENABLE_PROFILER": true,
+	"CHANGE_STREAM": true, "COLL_MOD": true, "COMPACT": true, "CONVERT_TO_CAPPED": true, "DROP_DATABASE": true,
+	"DROP_INDEX": true, "RE_INDEX": true, "RENAME_COLLECTION_SAME_DB": true, "SET_USER_WRITE_BLOCK": true,
+	"BYPASS_USER_WRITE_BLOCK": true, "LIST_SESSIONS": true, "KILL_ANY_SESSION": true, "COLL_STATS": true,
+	"CONN_POOL_STATS": true, "DB_HASH": true, "DB_STATS": true, "GET_CMD_LINE_OPTS": true, "GET_LOG": true,
+	"GET_PARAMETER": true, "GET_SHARD_MAP": true, "HOST_INFO": true, "IN_PROG": true, "LIST_DATABASES": true,
+	"LIST_COLLECTIONS": true, "LIST_INDEXES": true, "LIST_SHARDS": true, "NET_STAT": true, "REPL_SET_GET_CONFIG": true,
+	"REPL_SET_GET_STATUS": true, "SERVER_STATUS": true, "VALIDATE": true, "SHARDING_STATE": true, "TOP": true,
+	"SQL_GET_SCHEMA": true, "SQL_SET_SCHEMA": true, "VIEW_ALL_HISTORY": true, "OUT_TO_S3": true,
+	"STORAGE_GET_CONFIG": true, "STORAGE_SET_CONFIG": true, "FLUSH_ROUTER_CONFIG": true,
+}
+
+// builtInRoles are the MongoDB roles that can be inherited without being defined as custom roles
+var builtInRoles = map[string]bool{
+	"read": true, "readWrite": true, "dbAdmin": true, "dbOwner": true, "userAdmin": true, "clusterAdmin": true,
+	"clusterManager": true, "clusterMonitor": true, "hostManager": true, "backup": true, "restore": true,
+	"readAnyDatabase": true, "readWriteAnyDatabase": true, "userAdminAnyDatabase": true, "dbAdminAnyDatabase": true,
+	"root": true, "enableSharding": true, "atlasAdmin": true,
+}
+
+func validateActions(actions []Action) error {
+	var invalid []string
+	for _, a := range actions {
+		if a.Action == nil || !privilegeActions[*a.Action] {
+			invalid = append(invalid, util.SafeString(a.Action))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("unsupported privilege actions: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// missingInheritedRoles returns the inherited roles that are neither built-in nor existing custom roles of the project
+func missingInheritedRoles(inheritedRoles []InheritedRole, customRoles map[string][]string) []string {
+	var missing []string
+	for _, ir := range inheritedRoles {
+		role := util.SafeString(ir.Role)
+		if builtInRoles[role] {
+			continue
+		}
+		if _, ok := customRoles[role]; !ok {
+			missing = append(missing, role)
+		}
+	}
+	return missing
+}
+
+// customRoleGraph maps each custom role of the project to the custom roles it inherits
+func customRoleGraph(roles []admin.UserCustomDBRole) map[string][]string {
+	graph := make(map[string][]string, len(roles))
+	for i := range roles {
+		graph[roles[i].RoleName] = nil
+	}
+	for i := range roles {
+		for _, ir := range roles[i].InheritedRoles {
+			if _, ok := graph[ir.Role]; ok {
+				graph[roles[i].RoleName] = append(graph[roles[i].RoleName], ir.Role)
+			}
+		}
+	}
+	return graph
+}
+
+// findInheritanceCycle replaces the inherited roles of roleName in the project graph with the requested ones
+// and returns the inheritance path that leads back to roleName, or nil when there is no cycle
+func findInheritanceCycle(graph map[string][]string, roleName string, inheritedRoles []InheritedRole) []string {
+	var inherited []string
+	for _, ir := range inheritedRoles {
+		role := util.SafeString(ir.Role)
+		if _, ok := graph[role]; ok || role == roleName {
+			inherited = append(inherited, role)
+		}
+	}
+
+	visited := map[string]bool{}
+	var visit func(role string, path []string) []string
+	visit = func(role string, path []string) []string {
+		path = append(path, role)
+		if role == roleName {
+			return path
+		}
+		if visited[role] {
+			return nil
+		}
+		visited[role] = true
+		for _, next := range graph[role] {
+			if cycle := visit(next, path); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	for _, role := range inherited {
+		if cycle := visit(role, []string{roleName}); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// privilegeKeys flattens actions into one key per action and resource, so that two lists can be compared as sets
+func privilegeKeys(actions []admin.DatabasePrivilegeAction) map[string]bool {
+	keys := map[string]bool{}
+	for _, a := range actions {
+		if len(a.Resources) == 0 {
+			keys[a.Action] = true
+		}
+		for _, r := range a.Resources {
+			if r.Cluster {
+				keys[fmt.Sprintf("%s cluster", a.Action)] = true
+				continue
+			}
+			keys[fmt.Sprintf("%s %s.%s", a.Action, r.Db, r.Collection)] = true
+		}
+	}
+	return keys
+}
+
+// diffPrivileges returns the action/resource pairs that desired adds to and removes from current
+func diffPrivileges(current, desired []admin.DatabasePrivilegeAction) (added, removed []string) {
+	currentKeys := privilegeKeys(current)
+	desiredKeys := privilegeKeys(desired)
+	for k := range desiredKeys {
+		if !currentKeys[k] {
+			added = append(added, k)
+		}
+	}
+	for k := range currentKeys {
+		if !desiredKeys[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func areInheritedRolesEqual(current, desired []admin.DatabaseInheritedRole) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	currentRoles := map[string]bool{}
+	for _, ir := range current {
+		currentRoles[ir.Db+"."+ir.Role] = true
+	}
+	for _, ir := range desired {
+		if !currentRoles[ir.Db+"."+ir.Role] {
+			return false
+		}
+	}
+	return true
+}
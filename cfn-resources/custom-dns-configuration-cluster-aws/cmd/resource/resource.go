@@ -18,11 +18,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
 	"go.mongodb.org/atlas-sdk/v20231001001/admin"
@@ -30,6 +33,8 @@ import (
 
 var RequiredFields = []string{constants.ProjectID}
 
+const itemsPerPage = 500
+
 func setup() {
 	util.SetupLogger("mongodb-atlas-custom-dns-configuration-cluster-aws")
 }
@@ -111,6 +116,15 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	}
 
 	if isCustomAWSDNSSettingExists(currentModel, client) {
+		// disabling the setting must not block the teardown of the stack, the affected clusters are only reported
+		clusters, _, err := multiRegionPrivateEndpointClusters(client, *currentModel.ProjectId)
+		if err != nil {
+			_, _ = logger.Warnf("Error listing the clusters of Project %s : %s", *currentModel.ProjectId, err.Error())
+		} else if len(clusters) > 0 {
+			_, _ = logger.Warnf("Disabling custom AWS dns settings breaks the private endpoint connection strings of the multi-region clusters %s",
+				strings.Join(clusters, ", "))
+		}
+
 		enabled := false
 		currentModel.Enabled = &enabled
 		events, err := resourceCustomAWSDNSUpdate(req, prevModel, currentModel, client)
@@ -166,6 +180,34 @@ func isCustomAWSDNSSettingExists(currentModel *Model, client *util.MongoDBClient
 	return isExists
 }
 
+// multiRegionPrivateEndpointClusters returns the names of the clusters of the project that span several AWS regions
+// and have private endpoints attached. The private endpoint connection strings of such clusters rely on custom DNS.
+func multiRegionPrivateEndpointClusters(client *util.MongoDBClient, projectID string) ([]string, *http.Response, error) {
+	clusters, response, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.AdvancedClusterDescription, *http.Response, error) {
+		page, response, err := client.AtlasV2.ClustersApi.ListClustersWithParams(context.Background(), &admin.ListClustersApiParams{
+			GroupId:      projectID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, response, err
+		}
+		return page.Results, response, nil
+	})
+	if err != nil {
+		return nil, response, err
+	}
+
+	var names []string
+	for i := range clusters {
+		cluster := &clusters[i]
+		if cluster.ConnectionStrings != nil && len(cluster.ConnectionStrings.PrivateEndpoint) > 0 && len(util.ClusterRegions(cluster, constants.AWS)) > 1 {
+			names = append(names, util.SafeString(cluster.Name))
+		}
+	}
+	return names, response, nil
+}
+
 func customAWSDNSToModel(currentModel Model, regPrivateMode *admin.AWSCustomDNSEnabled) *Model {
 	out := &Model{
 		Profile:   currentModel.Profile,
@@ -0,0 +1,96 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+)
+
+// maxTemporaryUserLifetime is the furthest in the future Atlas accepts for deleteAfterDate
+const maxTemporaryUserLifetime = 7 * 24 * time.Hour
+
+func isTemporaryUser(model *Model) bool {
+	return util.IsStringPresent(model.DeleteAfterDate) || util.IsStringPresent(model.TimeToLive)
+}
+
+// expandDeleteAfterDate returns the deletion date to send to Atlas. It returns nil when the user is not
+// temporary or when the expiry did not change since prevModel, so that an Update does not extend the lifetime.
+// Atlas keeps the deletion date of a user updated without one, so a temporary user can't be made permanent.
+func expandDeleteAfterDate(prevModel, currentModel *Model, now time.Time) (*time.Time, error) {
+	if util.IsStringPresent(currentModel.DeleteAfterDate) && util.IsStringPresent(currentModel.TimeToLive) {
+		return nil, errors.New("DeleteAfterDate and TimeToLive cannot be provided together")
+	}
+	if prevModel != nil && isTemporaryUser(prevModel) && !isTemporaryUser(currentModel) {
+		return nil, errors.New("DeleteAfterDate and TimeToLive cannot be removed from a temporary user, replace the user to make it permanent")
+	}
+
+	if util.IsStringPresent(currentModel.TimeToLive) {
+		if prevModel != nil && util.AreStringPtrEqual(prevModel.TimeToLive, currentModel.TimeToLive) {
+			return nil, nil
+		}
+		ttl, err := time.ParseDuration(*currentModel.TimeToLive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TimeToLive %s: %w", *currentModel.TimeToLive, err)
+		}
+		if ttl <= 0 || ttl > maxTemporaryUserLifetime {
+			return nil, fmt.Errorf("TimeToLive must be greater than 0 and at most %s", maxTemporaryUserLifetime)
+		}
+		deleteAfterDate := now.Add(ttl).UTC()
+		return &deleteAfterDate, nil
+	}
+
+	if util.IsStringPresent(currentModel.DeleteAfterDate) {
+		if prevModel != nil && util.AreStringPtrEqual(prevModel.DeleteAfterDate, currentModel.DeleteAfterDate) {
+			return nil, nil
+		}
+		deleteAfterDate, err := util.StringToTime(*currentModel.DeleteAfterDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DeleteAfterDate %s, an ISO 8601 timestamp is expected: %w", *currentModel.DeleteAfterDate, err)
+		}
+		if !deleteAfterDate.After(now) {
+			return nil, fmt.Errorf("DeleteAfterDate %s must be in the future", *currentModel.DeleteAfterDate)
+		}
+		if deleteAfterDate.Sub(now) > maxTemporaryUserLifetime {
+			return nil, fmt.Errorf("DeleteAfterDate %s must be within one week from now", *currentModel.DeleteAfterDate)
+		}
+		deleteAfterDate = deleteAfterDate.UTC()
+		return &deleteAfterDate, nil
+	}
+
+	return nil, nil
+}
+
+// remainingLifetimeSeconds returns the seconds left until Atlas deletes the user, 0 if the date is past
+func remainingLifetimeSeconds(deleteAfterDate, now time.Time) int {
+	remaining := deleteAfterDate.Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return int(remaining.Seconds())
+}
+
+// flattenDeleteAfterDate keeps the date as written in the template unless Atlas reports a different instant
+func flattenDeleteAfterDate(modelValue *string, deleteAfterDate time.Time) *string {
+	if modelValue != nil {
+		if t := util.StringPtrToTimePtr(modelValue); t != nil && t.Equal(deleteAfterDate) {
+			return modelValue
+		}
+	}
+	return util.TimePtrToStringPtr(&deleteAfterDate)
+}
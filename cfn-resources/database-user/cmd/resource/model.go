@@ -4,19 +4,27 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	DeleteAfterDate   *string           `json:",omitempty"`
-	AWSIAMType        *string           `json:",omitempty"`
-	DatabaseName      *string           `json:",omitempty"`
-	Labels            []LabelDefinition `json:",omitempty"`
-	LdapAuthType      *string           `json:",omitempty"`
-	X509Type          *string           `json:",omitempty"`
-	Password          *string           `json:",omitempty"`
-	ProjectId         *string           `json:",omitempty"`
-	Roles             []RoleDefinition  `json:",omitempty"`
-	Scopes            []ScopeDefinition `json:",omitempty"`
-	UserCFNIdentifier *string           `json:",omitempty"`
-	Username          *string           `json:",omitempty"`
-	Profile           *string           `json:",omitempty"`
+	DeleteAfterDate          *string           `json:",omitempty"`
+	AWSIAMType               *string           `json:",omitempty"`
+	DatabaseName             *string           `json:",omitempty"`
+	Labels                   []LabelDefinition `json:",omitempty"`
+	LdapAuthType             *string           `json:",omitempty"`
+	X509Type                 *string           `json:",omitempty"`
+	Password                 *string           `json:",omitempty"`
+	ProjectId                *string           `json:",omitempty"`
+	Roles                    []RoleDefinition  `json:",omitempty"`
+	Scopes                   []ScopeDefinition `json:",omitempty"`
+	UserCFNIdentifier        *string           `json:",omitempty"`
+	Username                 *string           `json:",omitempty"`
+	Profile                  *string           `json:",omitempty"`
+	GeneratePassword         *bool             `json:",omitempty"`
+	PasswordSecretName       *string           `json:",omitempty"`
+	PasswordRotationToken    *string           `json:",omitempty"`
+	PasswordSecretArn        *string           `json:",omitempty"`
+	OIDCAuthType             *string           `json:",omitempty"`
+	AdoptExisting            *bool             `json:",omitempty"`
+	TimeToLive               *string           `json:",omitempty"`
+	RemainingLifetimeSeconds *int              `json:",omitempty"`
 }
 
 // LabelDefinition is autogenerated from the json schema
@@ -0,0 +1,103 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/secrets"
+)
+
+const generatedPasswordLength = 32
+
+// DatabaseUserSecret is the content of the secret that stores a generated password
+type DatabaseUserSecret struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	DatabaseName string `json:"databaseName"`
+	ProjectID    string `json:"projectId"`
+}
+
+func isPasswordGenerated(model *Model) bool {
+	return model.GeneratePassword != nil && *model.GeneratePassword
+}
+
+func isNoneOrEmpty(authType *string) bool {
+	return !util.IsStringPresent(authType) || *authType == authTypeNone
+}
+
+func validatePasswordGeneration(model *Model) error {
+	if !isPasswordGenerated(model) {
+		return nil
+	}
+	if util.IsStringPresent(model.Password) {
+		return errors.New("password cannot be provided when GeneratePassword is true")
+	}
+	if !util.IsStringPresent(model.PasswordSecretName) {
+		return errors.New("PasswordSecretName is required when GeneratePassword is true")
+	}
+	if !isNoneOrEmpty(model.LdapAuthType) || !isNoneOrEmpty(model.AWSIAMType) || !isNoneOrEmpty(model.X509Type) || !isNoneOrEmpty(model.OIDCAuthType) {
+		return errors.New("GeneratePassword is only supported for password authenticated users")
+	}
+	return nil
+}
+
+// isPasswordRotationRequested returns true when the rotation token changed between the previous and current model
+func isPasswordRotationRequested(prevModel, currentModel *Model) bool {
+	if !isPasswordGenerated(currentModel) || prevModel == nil {
+		return false
+	}
+	return !util.AreStringPtrEqual(prevModel.PasswordRotationToken, currentModel.PasswordRotationToken)
+}
+
+func newDatabaseUserSecret(model *Model, password string) DatabaseUserSecret {
+	return DatabaseUserSecret{
+		Username:     *model.Username,
+		Password:     password,
+		DatabaseName: *model.DatabaseName,
+		ProjectID:    *model.ProjectId,
+	}
+}
+
+func secretDescription(model *Model) *string {
+	return util.Pointer(fmt.Sprintf("MongoDB Atlas database user %s credentials for project %s", *model.Username, *model.ProjectId))
+}
+
+func createPasswordSecret(req *handler.Request, model *Model, password string) (*string, error) {
+	_, arn, err := secrets.Create(req, *model.PasswordSecretName, newDatabaseUserSecret(model, password), secretDescription(model))
+	return arn, err
+}
+
+func putPasswordSecret(req *handler.Request, model *Model, password string) (*string, error) {
+	_, arn, err := secrets.PutSecret(req, *model.PasswordSecretName, newDatabaseUserSecret(model, password), nil)
+	return arn, err
+}
+
+func getPasswordSecret(req *handler.Request, secretName string) (*DatabaseUserSecret, *string, error) {
+	secretString, arn, err := secrets.Get(req, secretName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secret DatabaseUserSecret
+	if err = json.Unmarshal([]byte(*secretString), &secret); err != nil {
+		return nil, nil, err
+	}
+	return &secret, arn, nil
+}
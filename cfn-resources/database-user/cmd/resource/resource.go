@@ -17,6 +17,8 @@ package resource
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/aws"
@@ -26,6 +28,7 @@ import (
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/secrets"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
 	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
@@ -36,6 +39,13 @@ var UpdateRequiredFields = []string{constants.DatabaseName, constants.ProjectID,
 var DeleteRequiredFields = []string{constants.ProjectID, constants.DatabaseName, constants.Username}
 var ListRequiredFields = []string{constants.ProjectID}
 
+const (
+	authTypeNone     = "NONE"
+	oidcUser         = "USER"
+	adminDatabase    = "admin"
+	externalDatabase = "$external"
+)
+
 func setup() {
 	util.SetupLogger("mongodb-atlas-database-user")
 }
@@ -61,7 +71,22 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	dbUser, err := setModel(currentModel)
+	if err := validatePasswordGeneration(currentModel); err != nil {
+		return progressevent.GetFailedEventByCode(fmt.Sprintf("Error Creating resource: %s", err.Error()),
+			cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	adopt := false
+	if isAdoptExisting(currentModel) {
+		exists, resp, err := databaseUserExists(client, currentModel)
+		if err != nil {
+			return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
+		}
+		adopt = exists
+	}
+
+	// the model is validated before the secret is created, so that an invalid model leaves no secret behind
+	dbUser, err := setModel(nil, currentModel, adopt)
 	if err != nil {
 		return handler.ProgressEvent{
 			OperationStatus:  handler.Failed,
@@ -69,13 +94,48 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
 	}
 
+	if isPasswordGenerated(currentModel) {
+		password, err := secrets.GetRandomPassword(&req, generatedPasswordLength)
+		if err != nil {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error generating password: %s", err.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+		currentModel.Password = password
+		dbUser.Password = password
+
+		arn, err := createPasswordSecret(&req, currentModel, *password)
+		if err != nil {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error creating secret %s: %s", *currentModel.PasswordSecretName, err.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+		currentModel.PasswordSecretArn = arn
+	}
+
 	groupID := *currentModel.ProjectId
 
-	_, resp, err := client.AtlasV2.DatabaseUsersApi.CreateDatabaseUser(context.Background(), groupID, dbUser).Execute()
+	var resp *http.Response
+	if adopt {
+		_, _ = logger.Debugf("Adopting existing database user %s", *currentModel.Username)
+		_, resp, err = client.AtlasV2.DatabaseUsersApi.UpdateDatabaseUser(context.Background(), groupID, *currentModel.DatabaseName, *currentModel.Username, dbUser).Execute()
+	} else {
+		_, resp, err = client.AtlasV2.DatabaseUsersApi.CreateDatabaseUser(context.Background(), groupID, dbUser).Execute()
+	}
 	if err != nil {
+		if isPasswordGenerated(currentModel) {
+			_ = secrets.Delete(&req, *currentModel.PasswordSecretName)
+		}
+		if resp != nil && resp.StatusCode == http.StatusConflict {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Database user %s already exists, set AdoptExisting to manage it or import it into the stack: %s",
+				*currentModel.Username, err.Error()), cloudformation.HandlerErrorCodeAlreadyExists), nil
+		}
 		return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
 	}
 
+	if isPasswordGenerated(currentModel) {
+		// the generated password is only available through the secret
+		currentModel.Password = nil
+	}
+
 	updateUserCFNIdentifier(currentModel)
 
 	return handler.ProgressEvent{
@@ -111,15 +171,10 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 
 	currentModel.DatabaseName = &databaseUser.DatabaseName
 
-	if currentModel.LdapAuthType != nil {
-		currentModel.LdapAuthType = databaseUser.LdapAuthType
-	}
-	if currentModel.AWSIAMType != nil {
-		currentModel.AWSIAMType = databaseUser.AwsIAMType
-	}
-	if currentModel.X509Type != nil {
-		currentModel.X509Type = databaseUser.X509Type
-	}
+	currentModel.LdapAuthType = authTypeFromAtlas(currentModel.LdapAuthType, databaseUser.LdapAuthType)
+	currentModel.AWSIAMType = authTypeFromAtlas(currentModel.AWSIAMType, databaseUser.AwsIAMType)
+	currentModel.X509Type = authTypeFromAtlas(currentModel.X509Type, databaseUser.X509Type)
+	currentModel.OIDCAuthType = authTypeFromAtlas(currentModel.OIDCAuthType, databaseUser.OidcAuthType)
 	currentModel.Username = &databaseUser.Username
 	_, _ = logger.Debugf("databaseUser:%+v", databaseUser)
 	var roles []RoleDefinition
@@ -148,6 +203,24 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		labels = append(labels, label)
 	}
 	currentModel.Labels = labels
+	currentModel.Scopes = flattenScopes(databaseUser.Scopes)
+
+	currentModel.RemainingLifetimeSeconds = nil
+	if databaseUser.DeleteAfterDate != nil {
+		if util.IsStringPresent(currentModel.DeleteAfterDate) {
+			currentModel.DeleteAfterDate = flattenDeleteAfterDate(currentModel.DeleteAfterDate, *databaseUser.DeleteAfterDate)
+		}
+		currentModel.RemainingLifetimeSeconds = util.Pointer(remainingLifetimeSeconds(*databaseUser.DeleteAfterDate, time.Now()))
+	}
+
+	if isPasswordGenerated(currentModel) && util.IsStringPresent(currentModel.PasswordSecretName) {
+		_, arn, secretErr := secrets.Get(&req, *currentModel.PasswordSecretName)
+		if secretErr != nil {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error reading secret %s: %s", *currentModel.PasswordSecretName, secretErr.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+		currentModel.PasswordSecretArn = arn
+	}
 
 	updateUserCFNIdentifier(currentModel)
 
@@ -173,7 +246,31 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	dbUser, err := setModel(currentModel)
+	if err := validatePasswordGeneration(currentModel); err != nil {
+		return progressevent.GetFailedEventByCode(fmt.Sprintf("Error Updating resource: %s", err.Error()),
+			cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	rotatePassword := isPasswordRotationRequested(prevModel, currentModel)
+	var previousSecret *DatabaseUserSecret
+	if rotatePassword {
+		secret, _, err := getPasswordSecret(&req, *currentModel.PasswordSecretName)
+		if err != nil {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error reading secret %s: %s", *currentModel.PasswordSecretName, err.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+		previousSecret = secret
+
+		password, err := secrets.GetRandomPassword(&req, generatedPasswordLength)
+		if err != nil {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error generating password: %s", err.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+		currentModel.Password = password
+	}
+
+	// a user that is updated was either created or adopted by the resource
+	dbUser, err := setModel(prevModel, currentModel, isAdoptExisting(currentModel))
 	if err != nil {
 		return handler.ProgressEvent{
 			OperationStatus:  handler.Failed,
@@ -188,6 +285,25 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
 	}
 
+	if rotatePassword {
+		arn, secretErr := putPasswordSecret(&req, currentModel, *currentModel.Password)
+		if secretErr != nil {
+			// restore the previous password so that the secret keeps matching the user in Atlas
+			dbUser.Password = &previousSecret.Password
+			_, _, rollbackErr := client.AtlasV2.DatabaseUsersApi.UpdateDatabaseUser(context.Background(), groupID, *currentModel.DatabaseName, *currentModel.Username, dbUser).Execute()
+			if rollbackErr != nil {
+				_, _ = logger.Warnf("Error restoring previous password of user %s: %s", *currentModel.Username, rollbackErr.Error())
+			}
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error updating secret %s: %s", *currentModel.PasswordSecretName, secretErr.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
+		currentModel.PasswordSecretArn = arn
+	}
+
+	if isPasswordGenerated(currentModel) {
+		currentModel.Password = nil
+	}
+
 	updateUserCFNIdentifier(currentModel)
 
 	return handler.ProgressEvent{
@@ -218,7 +334,19 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	username := *currentModel.Username
 	_, resp, err := client.AtlasV2.DatabaseUsersApi.DeleteDatabaseUser(context.Background(), groupID, databaseName, username).Execute()
 	if err != nil {
-		return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
+		// Atlas removes temporary users on its own once they expire, there is nothing left to delete
+		expired := isTemporaryUser(currentModel) && resp != nil && resp.StatusCode == http.StatusNotFound
+		if !expired {
+			return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
+		}
+		_, _ = logger.Debugf("Temporary database user %s was already deleted by Atlas", username)
+	}
+
+	if isPasswordGenerated(currentModel) && util.IsStringPresent(currentModel.PasswordSecretName) {
+		if secretErr := secrets.Delete(&req, *currentModel.PasswordSecretName); secretErr != nil {
+			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error deleting secret %s: %s", *currentModel.PasswordSecretName, secretErr.Error()),
+				cloudformation.HandlerErrorCodeServiceInternalError), nil
+		}
 	}
 
 	updateUserCFNIdentifier(currentModel)
@@ -259,9 +387,13 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		var model = Model{
 			DatabaseName: &databaseUser.DatabaseName,
 			LdapAuthType: databaseUser.LdapAuthType,
+			AWSIAMType:   databaseUser.AwsIAMType,
 			X509Type:     databaseUser.X509Type,
+			OIDCAuthType: databaseUser.OidcAuthType,
 			Username:     &databaseUser.Username,
 			ProjectId:    currentModel.ProjectId,
+			Profile:      currentModel.Profile,
+			Scopes:       flattenScopes(databaseUser.Scopes),
 		}
 
 		var roles []RoleDefinition
@@ -301,7 +433,9 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	}, nil
 }
 
-func setModel(currentModel *Model) (*admin.CloudDatabaseUser, error) {
+// setModel returns the user to send to Atlas. adopted tells whether the user already exists in Atlas and was
+// adopted by the resource.
+func setModel(prevModel, currentModel *Model, adopted bool) (*admin.CloudDatabaseUser, error) {
 	var roles []admin.DatabaseUserRole
 	for i := range currentModel.Roles {
 		r := currentModel.Roles[i]
@@ -340,7 +474,11 @@ func setModel(currentModel *Model) (*admin.CloudDatabaseUser, error) {
 
 	groupID := *currentModel.ProjectId
 
-	none := "NONE"
+	if err := validateOIDCAuthType(currentModel); err != nil {
+		return nil, err
+	}
+
+	none := authTypeNone
 	if currentModel.LdapAuthType == nil {
 		currentModel.LdapAuthType = &none
 	}
@@ -350,17 +488,23 @@ func setModel(currentModel *Model) (*admin.CloudDatabaseUser, error) {
 	if currentModel.X509Type == nil {
 		currentModel.X509Type = &none
 	}
+	if currentModel.OIDCAuthType == nil {
+		currentModel.OIDCAuthType = &none
+	}
 
-	if currentModel.Password == nil {
-		if (*currentModel.LdapAuthType == none) && (*currentModel.AWSIAMType == none) && (*currentModel.X509Type == none) {
-			err := fmt.Errorf("password cannot be empty if not LDAP or IAM or X509 is not provided")
+	// a generated password is only sent to Atlas on create and rotation, otherwise the current one is kept.
+	// The same applies to adopted users, whose existing password may not be known.
+	if currentModel.Password == nil && !isPasswordGenerated(currentModel) && !adopted {
+		if (*currentModel.LdapAuthType == none) && (*currentModel.AWSIAMType == none) && (*currentModel.X509Type == none) && (*currentModel.OIDCAuthType == none) {
+			err := fmt.Errorf("password cannot be empty if not LDAP or IAM or X509 or OIDC is not provided")
 			return nil, err
 		}
 		currentModel.Password = aws.String("")
 	}
 
-	if (currentModel.X509Type != &none) || (currentModel.DeleteAfterDate == nil) {
-		currentModel.DeleteAfterDate = aws.String("")
+	deleteAfterDate, err := expandDeleteAfterDate(prevModel, currentModel, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
 	user := &admin.CloudDatabaseUser{
@@ -373,7 +517,8 @@ func setModel(currentModel *Model) (*admin.CloudDatabaseUser, error) {
 		LdapAuthType:    currentModel.LdapAuthType,
 		AwsIAMType:      currentModel.AWSIAMType,
 		X509Type:        currentModel.X509Type,
-		DeleteAfterDate: util.StringPtrToTimePtr(currentModel.DeleteAfterDate),
+		OidcAuthType:    currentModel.OIDCAuthType,
+		DeleteAfterDate: deleteAfterDate,
 	}
 
 	if currentModel.Password != nil {
@@ -383,6 +528,64 @@ func setModel(currentModel *Model) (*admin.CloudDatabaseUser, error) {
 	return user, nil
 }
 
+// validateOIDCAuthType checks that OIDC users have no password, use no other authentication method
+// and are created on the authentication database Atlas expects for the OIDC type
+func validateOIDCAuthType(model *Model) error {
+	if isNoneOrEmpty(model.OIDCAuthType) {
+		return nil
+	}
+	if util.IsStringPresent(model.Password) || isPasswordGenerated(model) {
+		return fmt.Errorf("password cannot be provided for OIDC users")
+	}
+	if !isNoneOrEmpty(model.LdapAuthType) || !isNoneOrEmpty(model.AWSIAMType) || !isNoneOrEmpty(model.X509Type) {
+		return fmt.Errorf("OIDCAuthType cannot be combined with LdapAuthType, AWSIAMType or X509Type")
+	}
+
+	expectedDatabase := adminDatabase
+	if *model.OIDCAuthType == oidcUser {
+		expectedDatabase = externalDatabase
+	}
+	if *model.DatabaseName != expectedDatabase {
+		return fmt.Errorf("DatabaseName must be %s for OIDCAuthType %s", expectedDatabase, *model.OIDCAuthType)
+	}
+	return nil
+}
+
+func isAdoptExisting(model *Model) bool {
+	return model.AdoptExisting != nil && *model.AdoptExisting
+}
+
+func databaseUserExists(client *util.MongoDBClient, model *Model) (bool, *http.Response, error) {
+	_, resp, err := client.AtlasV2.DatabaseUsersApi.GetDatabaseUser(context.Background(), *model.ProjectId, *model.DatabaseName, *model.Username).Execute()
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, resp, nil
+		}
+		return false, resp, err
+	}
+	return true, resp, nil
+}
+
+// authTypeFromAtlas leaves the authentication type unset when it was not defined and Atlas reports NONE,
+// so that a model read from the primary identifier alone matches a template that omits the property
+func authTypeFromAtlas(modelValue, atlasValue *string) *string {
+	if modelValue == nil && isNoneOrEmpty(atlasValue) {
+		return nil
+	}
+	return atlasValue
+}
+
+func flattenScopes(userScopes []admin.UserScope) []ScopeDefinition {
+	var scopes []ScopeDefinition
+	for i := range userScopes {
+		scopes = append(scopes, ScopeDefinition{
+			Name: &userScopes[i].Name,
+			Type: &userScopes[i].Type,
+		})
+	}
+	return scopes
+}
+
 func updateUserCFNIdentifier(model *Model) {
 	cfnid := fmt.Sprintf("%s-%s", *model.Username, *model.ProjectId)
 	model.UserCFNIdentifier = &cfnid
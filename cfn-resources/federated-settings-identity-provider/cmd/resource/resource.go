@@ -0,0 +1,428 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/federation"
+	log "github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	progressevents "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
+)
+
+var CreateRequiredFields = []string{constants.FederationSettingsID, constants.Protocol}
+var ReadRequiredFields = []string{constants.FederationSettingsID, constants.ID}
+var UpdateRequiredFields = []string{constants.FederationSettingsID, constants.ID}
+var DeleteRequiredFields = []string{constants.FederationSettingsID, constants.ID}
+var ListRequiredFields = []string{constants.FederationSettingsID}
+
+const (
+	protocolSAML = "SAML"
+	protocolOIDC = "OIDC"
+
+	idpTypeWorkforce       = "WORKFORCE"
+	authorizationTypeGroup = "GROUP"
+)
+
+// identityProvider is the identity provider of the Admin API 2023-11-15, the atlas-sdk version in use only
+// knows SAML identity providers
+type identityProvider struct {
+	ID                         *string   `json:"id,omitempty"`
+	OktaIdpID                  *string   `json:"oktaIdpId,omitempty"`
+	Protocol                   *string   `json:"protocol,omitempty"`
+	IdpType                    *string   `json:"idpType,omitempty"`
+	DisplayName                *string   `json:"displayName,omitempty"`
+	Description                *string   `json:"description,omitempty"`
+	IssuerURI                  *string   `json:"issuerUri,omitempty"`
+	AssociatedDomains          *[]string `json:"associatedDomains,omitempty"`
+	SsoURL                     *string   `json:"ssoUrl,omitempty"`
+	RequestBinding             *string   `json:"requestBinding,omitempty"`
+	ResponseSignatureAlgorithm *string   `json:"responseSignatureAlgorithm,omitempty"`
+	SsoDebugEnabled            *bool     `json:"ssoDebugEnabled,omitempty"`
+	Status                     *string   `json:"status,omitempty"`
+	AcsURL                     *string   `json:"acsUrl,omitempty"`
+	AudienceURI                *string   `json:"audienceUri,omitempty"`
+	ClientID                   *string   `json:"clientId,omitempty"`
+	Audience                   *string   `json:"audience,omitempty"`
+	AuthorizationType          *string   `json:"authorizationType,omitempty"`
+	RequestedScopes            *[]string `json:"requestedScopes,omitempty"`
+	GroupsClaim                *string   `json:"groupsClaim,omitempty"`
+	UserClaim                  *string   `json:"userClaim,omitempty"`
+}
+
+func validateModel(fields []string, model *Model) *handler.ProgressEvent {
+	return validator.ValidateModel(fields, model)
+}
+
+func setup() {
+	util.SetupLogger("mongodb-atlas-FederatedSettingsIdentityProvider")
+}
+
+// validateProtocolFields checks that the model only sets the fields of its protocol. Atlas doesn't create SAML
+// identity providers through the Admin API, so a SAML identity provider has to be adopted by IdentityProviderId.
+func validateProtocolFields(model *Model, isCreate bool) error {
+	samlFields := model.SsoUrl != nil || model.RequestBinding != nil || model.ResponseSignatureAlgorithm != nil ||
+		model.SsoDebugEnabled != nil || model.Status != nil
+	oidcFields := model.ClientId != nil || model.Audience != nil || model.AuthorizationType != nil ||
+		model.RequestedScopes != nil || model.GroupsClaim != nil || model.UserClaim != nil || model.IdpType != nil
+
+	switch util.SafeString(model.Protocol) {
+	case protocolSAML:
+		if oidcFields {
+			return errors.New("ClientId, Audience, AuthorizationType, RequestedScopes, GroupsClaim, UserClaim and IdpType only apply to OIDC identity providers")
+		}
+		if isCreate && !util.IsStringPresent(model.IdentityProviderId) {
+			return errors.New("IdentityProviderId is required for SAML, Atlas only creates SAML identity providers in the Federation Management Console")
+		}
+	case protocolOIDC:
+		if samlFields {
+			return errors.New("SsoUrl, RequestBinding, ResponseSignatureAlgorithm, SsoDebugEnabled and Status only apply to SAML identity providers")
+		}
+		if isCreate && util.IsStringPresent(model.IdentityProviderId) {
+			return errors.New("IdentityProviderId only applies to SAML, OIDC identity providers are created by this resource")
+		}
+		if !util.IsStringPresent(model.IssuerUri) || !util.IsStringPresent(model.Audience) || !util.IsStringPresent(model.UserClaim) {
+			return errors.New("IssuerUri, Audience and UserClaim are required for OIDC identity providers")
+		}
+		if util.SafeString(model.IdpType) != "" && *model.IdpType != idpTypeWorkforce && util.IsStringPresent(model.ClientId) {
+			return errors.New("ClientId only applies to WORKFORCE identity providers")
+		}
+		if (model.IdpType == nil || *model.IdpType == idpTypeWorkforce) && !util.IsStringPresent(model.ClientId) {
+			return errors.New("ClientId is required for WORKFORCE identity providers")
+		}
+		if util.SafeString(model.AuthorizationType) == authorizationTypeGroup && !util.IsStringPresent(model.GroupsClaim) {
+			return errors.New("GroupsClaim is required when AuthorizationType is GROUP")
+		}
+	default:
+		return fmt.Errorf("unsupported Protocol %s", util.SafeString(model.Protocol))
+	}
+	return nil
+}
+
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Create() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(CreateRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+	if err := validateProtocolFields(currentModel, true); err != nil {
+		return progressevents.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	federationSettingsID := *currentModel.FederationSettingsId
+	idp := new(identityProvider)
+	if *currentModel.Protocol == protocolOIDC {
+		res, err := federation.Call(client, http.MethodPost, federation.SettingsPath(federationSettingsID, "identityProviders"), newIdentityProvider(nil, currentModel), idp)
+		if err != nil {
+			return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error creating identity provider : %s", err.Error()), res), nil
+		}
+	} else {
+		// adopt the SAML identity provider and apply the configuration of the template
+		existing, res, err := getIdentityProvider(client, federationSettingsID, *currentModel.IdentityProviderId)
+		if err != nil {
+			return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting identity provider : %s", err.Error()), res), nil
+		}
+		if util.SafeString(existing.Protocol) != protocolSAML {
+			return progressevents.GetFailedEventByCode(fmt.Sprintf("identity provider %s is not a SAML identity provider", *currentModel.IdentityProviderId),
+				cloudformation.HandlerErrorCodeInvalidRequest), nil
+		}
+		res, err = federation.Call(client, http.MethodPatch, identityProviderPath(federationSettingsID, *currentModel.IdentityProviderId),
+			newIdentityProvider(nil, currentModel), idp)
+		if err != nil {
+			return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error updating identity provider : %s", err.Error()), res), nil
+		}
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		ResourceModel:   identityProviderToModel(client, currentModel, idp),
+	}, nil
+}
+
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Read() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(ReadRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	idp, res, err := getIdentityProvider(client, *currentModel.FederationSettingsId, *currentModel.Id)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting identity provider : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		ResourceModel:   identityProviderToModel(client, currentModel, idp),
+	}, nil
+}
+
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Update() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(UpdateRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+	if err := validateProtocolFields(currentModel, false); err != nil {
+		return progressevents.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	federationSettingsID := *currentModel.FederationSettingsId
+	if _, res, err := getIdentityProvider(client, federationSettingsID, *currentModel.Id); err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting identity provider : %s", err.Error()), res), nil
+	}
+
+	idp := new(identityProvider)
+	res, err := federation.Call(client, http.MethodPatch, identityProviderPath(federationSettingsID, *currentModel.Id), newIdentityProvider(prevModel, currentModel), idp)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error updating identity provider : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Update Complete",
+		ResourceModel:   identityProviderToModel(client, currentModel, idp),
+	}, nil
+}
+
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Delete() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(DeleteRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	federationSettingsID := *currentModel.FederationSettingsId
+	idp, res, err := getIdentityProvider(client, federationSettingsID, *currentModel.Id)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting identity provider : %s", err.Error()), res), nil
+	}
+
+	// SAML identity providers can only be deleted in the Federation Management Console, the stack only releases them
+	if util.SafeString(idp.Protocol) == protocolSAML {
+		_, _ = log.Warnf("SAML identity provider %s is no longer managed by the stack but still exists in Atlas", *currentModel.Id)
+		return handler.ProgressEvent{
+			OperationStatus: handler.Success,
+			Message:         "Delete Complete",
+		}, nil
+	}
+
+	res, err = federation.Call(client, http.MethodDelete, identityProviderPath(federationSettingsID, *currentModel.Id), nil, nil)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error deleting identity provider : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+	}, nil
+}
+
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("List() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(ListRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	// Atlas only lists SAML identity providers unless asked for every protocol
+	query := url.Values{"protocol": {protocolSAML, protocolOIDC}, "idpType": {idpTypeWorkforce, "WORKLOAD"}}
+	idps, res, err := federation.ListAll[identityProvider](client, federation.SettingsPath(*currentModel.FederationSettingsId, "identityProviders"), query)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error listing identity providers : %s", err.Error()), res), nil
+	}
+
+	models := make([]interface{}, 0)
+	for i := range idps {
+		model := &Model{
+			Profile:              currentModel.Profile,
+			FederationSettingsId: currentModel.FederationSettingsId,
+		}
+		readIdentityProvider(model, &idps[i])
+		models = append(models, model)
+	}
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List",
+		ResourceModels:  models,
+	}, nil
+}
+
+func identityProviderPath(federationSettingsID, id string) string {
+	return federation.SettingsPath(federationSettingsID, "identityProviders", id)
+}
+
+func getIdentityProvider(client *util.MongoDBClient, federationSettingsID, id string) (*identityProvider, *http.Response, error) {
+	idp := new(identityProvider)
+	res, err := federation.Call(client, http.MethodGet, identityProviderPath(federationSettingsID, id), nil, idp)
+	if err != nil {
+		return nil, res, err
+	}
+	return idp, res, nil
+}
+
+// newIdentityProvider builds the request body from the model. Lists that were removed from the template
+// since prevModel are sent empty, so Atlas clears them.
+func newIdentityProvider(prevModel, currentModel *Model) *identityProvider {
+	idp := &identityProvider{
+		DisplayName:                currentModel.DisplayName,
+		Description:                currentModel.Description,
+		IssuerURI:                  currentModel.IssuerUri,
+		SsoURL:                     currentModel.SsoUrl,
+		RequestBinding:             currentModel.RequestBinding,
+		ResponseSignatureAlgorithm: currentModel.ResponseSignatureAlgorithm,
+		SsoDebugEnabled:            currentModel.SsoDebugEnabled,
+		Status:                     currentModel.Status,
+		ClientID:                   currentModel.ClientId,
+		Audience:                   currentModel.Audience,
+		AuthorizationType:          currentModel.AuthorizationType,
+		GroupsClaim:                currentModel.GroupsClaim,
+		UserClaim:                  currentModel.UserClaim,
+	}
+	if currentModel.AssociatedDomains != nil || (prevModel != nil && prevModel.AssociatedDomains != nil) {
+		idp.AssociatedDomains = util.Pointer(append([]string{}, currentModel.AssociatedDomains...))
+	}
+	if currentModel.RequestedScopes != nil || (prevModel != nil && prevModel.RequestedScopes != nil) {
+		idp.RequestedScopes = util.Pointer(append([]string{}, currentModel.RequestedScopes...))
+	}
+	if prevModel == nil && util.SafeString(currentModel.Protocol) == protocolOIDC {
+		idp.Protocol = currentModel.Protocol
+		idp.IdpType = currentModel.IdpType
+		if idp.IdpType == nil {
+			idp.IdpType = util.Pointer(idpTypeWorkforce)
+		}
+	}
+	return idp
+}
+
+func identityProviderToModel(client *util.MongoDBClient, currentModel *Model, idp *identityProvider) *Model {
+	readIdentityProvider(currentModel, idp)
+	if util.SafeString(idp.Protocol) != protocolSAML || !util.IsStringPresent(idp.OktaIdpID) {
+		return currentModel
+	}
+
+	metadata, _, err := client.AtlasV2.FederatedAuthenticationApi.GetIdentityProviderMetadata(context.Background(),
+		*currentModel.FederationSettingsId, *idp.OktaIdpID).Execute()
+	if err != nil {
+		_, _ = log.Warnf("error getting metadata of identity provider %s: %v", util.SafeString(idp.ID), err)
+		return currentModel
+	}
+	currentModel.Metadata = &metadata
+	return currentModel
+}
+
+func readIdentityProvider(model *Model, idp *identityProvider) {
+	model.Id = idp.ID
+	model.OktaIdpId = idp.OktaIdpID
+	model.Protocol = idp.Protocol
+	model.DisplayName = idp.DisplayName
+	model.Description = idp.Description
+	model.IssuerUri = idp.IssuerURI
+	model.AssociatedDomains = nil
+	if idp.AssociatedDomains != nil && len(*idp.AssociatedDomains) > 0 {
+		model.AssociatedDomains = *idp.AssociatedDomains
+	}
+
+	if util.SafeString(idp.Protocol) == protocolSAML {
+		model.IdentityProviderId = idp.ID
+		model.SsoUrl = idp.SsoURL
+		model.RequestBinding = idp.RequestBinding
+		model.ResponseSignatureAlgorithm = idp.ResponseSignatureAlgorithm
+		model.SsoDebugEnabled = idp.SsoDebugEnabled
+		model.Status = idp.Status
+		model.AcsUrl = idp.AcsURL
+		model.AudienceUri = idp.AudienceURI
+		return
+	}
+
+	model.IdpType = idp.IdpType
+	model.ClientId = idp.ClientID
+	model.Audience = idp.Audience
+	model.AuthorizationType = idp.AuthorizationType
+	model.GroupsClaim = idp.GroupsClaim
+	model.UserClaim = idp.UserClaim
+	model.RequestedScopes = nil
+	if idp.RequestedScopes != nil && len(*idp.RequestedScopes) > 0 {
+		model.RequestedScopes = *idp.RequestedScopes
+	}
+}
@@ -0,0 +1,34 @@
+#!/usr/bin/env bash
+# cfn-test-create-inputs.sh
+#
+# This tool generates json files in the inputs/ for `cfn test`.
+#
+
+# NOTE: You need to set the Federation Settings Id in order to execute this resource.
+#       You can get the Federation Settings Id on Atlas UI under the 'Manage Federation Settings' console
+
+set -o nounset
+set -o pipefail
+WORDTOREMOVE="template."
+
+#set profile
+profile="federation"
+if [ ${MONGODB_ATLAS_PROFILE+x} ];then
+    echo "profile set to ${MONGODB_ATLAS_PROFILE}"
+    profile=${MONGODB_ATLAS_PROFILE}
+fi
+
+rm -rf inputs
+mkdir inputs
+
+cd "$(dirname "$0")" || exit
+for inputFile in inputs_*; do
+	outputFile=${inputFile//$WORDTOREMOVE/}
+	jq --arg FederationSettingsId "$ATLAS_FEDERATED_SETTINGS_ID" \
+		--arg profile "$profile" \
+		'.Profile?|=$profile | .FederationSettingsId?|=$FederationSettingsId' \
+		"$inputFile" >"../inputs/$outputFile"
+done
+cd ..
+
+ls -l inputs
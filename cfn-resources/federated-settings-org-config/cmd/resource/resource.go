@@ -0,0 +1,337 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/federation"
+	log "github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	progressevents "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
+)
+
+var CreateRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
+var ReadRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
+var UpdateRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
+var DeleteRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
+var ListRequiredFields = []string{constants.FederationSettingsID}
+
+// connectedOrgConfig is the connected organization configuration of the Admin API 2023-11-15. Unlike the
+// atlas-sdk model, every field is optional so PATCH requests only carry what the template declares.
+type connectedOrgConfig struct {
+	OrgID                         *string         `json:"orgId,omitempty"`
+	IdentityProviderID            *string         `json:"identityProviderId,omitempty"`
+	DataAccessIdentityProviderIDs *[]string       `json:"dataAccessIdentityProviderIds,omitempty"`
+	DomainAllowList               *[]string       `json:"domainAllowList,omitempty"`
+	DomainRestrictionEnabled      *bool           `json:"domainRestrictionEnabled,omitempty"`
+	PostAuthRoleGrants            *[]string       `json:"postAuthRoleGrants,omitempty"`
+	UserConflicts                 []federatedUser `json:"userConflicts,omitempty"`
+}
+
+type federatedUser struct {
+	EmailAddress *string `json:"emailAddress,omitempty"`
+	FirstName    *string `json:"firstName,omitempty"`
+	LastName     *string `json:"lastName,omitempty"`
+	UserID       *string `json:"userId,omitempty"`
+}
+
+func validateModel(fields []string, model *Model) *handler.ProgressEvent {
+	return validator.ValidateModel(fields, model)
+}
+
+func setup() {
+	util.SetupLogger("mongodb-atlas-FederatedSettingsOrgConfig")
+}
+
+// validateDomainRestriction rejects a domain restriction without allowed domains, which would lock every
+// user out of the organization
+func validateDomainRestriction(model *Model) error {
+	if model.DomainRestrictionEnabled != nil && *model.DomainRestrictionEnabled && len(model.DomainAllowList) == 0 {
+		return errors.New("DomainAllowList must contain at least one domain when DomainRestrictionEnabled is true")
+	}
+	return nil
+}
+
+func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Create() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(CreateRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+	if err := validateDomainRestriction(currentModel); err != nil {
+		return progressevents.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	// connecting an organization is an update of its configuration, an organization connected in the
+	// Federation Management Console is adopted the same way
+	config, res, err := updateConnectedOrgConfig(client, nil, currentModel)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error connecting organization : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		ResourceModel:   connectedOrgConfigToModel(client, currentModel, config),
+	}, nil
+}
+
+func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Read() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(ReadRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	config, res, err := getConnectedOrgConfig(client, *currentModel.FederationSettingsId, *currentModel.OrgId)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting connected organization : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		ResourceModel:   connectedOrgConfigToModel(client, currentModel, config),
+	}, nil
+}
+
+func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Update() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(UpdateRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+	if err := validateDomainRestriction(currentModel); err != nil {
+		return progressevents.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	if _, res, err := getConnectedOrgConfig(client, *currentModel.FederationSettingsId, *currentModel.OrgId); err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting connected organization : %s", err.Error()), res), nil
+	}
+
+	config, res, err := updateConnectedOrgConfig(client, prevModel, currentModel)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error updating connected organization : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Update Complete",
+		ResourceModel:   connectedOrgConfigToModel(client, currentModel, config),
+	}, nil
+}
+
+func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("Delete() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(DeleteRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	path := connectedOrgConfigPath(*currentModel.FederationSettingsId, *currentModel.OrgId)
+	if _, res, err := getConnectedOrgConfig(client, *currentModel.FederationSettingsId, *currentModel.OrgId); err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting connected organization : %s", err.Error()), res), nil
+	}
+
+	res, err := federation.Call(client, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error disconnecting organization : %s", err.Error()), res), nil
+	}
+
+	// Response
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Delete Complete",
+	}, nil
+}
+
+func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
+	setup()
+	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
+
+	_, _ = log.Debugf("List() currentModel:%+v", currentModel)
+
+	// Validation
+	modelValidation := validateModel(ListRequiredFields, currentModel)
+	if modelValidation != nil {
+		return *modelValidation, nil
+	}
+
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
+	if pe != nil {
+		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
+		return *pe, nil
+	}
+
+	configs, res, err := federation.ListAll[connectedOrgConfig](client, federation.SettingsPath(*currentModel.FederationSettingsId, "connectedOrgConfigs"), nil)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error listing connected organizations : %s", err.Error()), res), nil
+	}
+
+	models := make([]interface{}, 0)
+	for i := range configs {
+		model := &Model{
+			Profile:              currentModel.Profile,
+			FederationSettingsId: currentModel.FederationSettingsId,
+		}
+		readConnectedOrgConfig(model, &configs[i])
+		models = append(models, model)
+	}
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "List",
+		ResourceModels:  models,
+	}, nil
+}
+
+func connectedOrgConfigPath(federationSettingsID, orgID string) string {
+	return federation.SettingsPath(federationSettingsID, "connectedOrgConfigs", orgID)
+}
+
+func getConnectedOrgConfig(client *util.MongoDBClient, federationSettingsID, orgID string) (*connectedOrgConfig, *http.Response, error) {
+	config := new(connectedOrgConfig)
+	res, err := federation.Call(client, http.MethodGet, connectedOrgConfigPath(federationSettingsID, orgID), nil, config)
+	if err != nil {
+		return nil, res, err
+	}
+	return config, res, nil
+}
+
+// updateConnectedOrgConfig sends the declared configuration. Lists that were removed from the template since
+// prevModel are sent empty, so Atlas clears them.
+func updateConnectedOrgConfig(client *util.MongoDBClient, prevModel, currentModel *Model) (*connectedOrgConfig, *http.Response, error) {
+	request := &connectedOrgConfig{
+		OrgID:                    currentModel.OrgId,
+		IdentityProviderID:       currentModel.IdentityProviderId,
+		DomainRestrictionEnabled: currentModel.DomainRestrictionEnabled,
+	}
+	declared := func(current, previous []string) *[]string {
+		if current == nil && (prevModel == nil || previous == nil) {
+			return nil
+		}
+		return util.Pointer(append([]string{}, current...))
+	}
+	var prevDataAccess, prevAllowList, prevGrants []string
+	if prevModel != nil {
+		prevDataAccess, prevAllowList, prevGrants = prevModel.DataAccessIdentityProviderIds, prevModel.DomainAllowList, prevModel.PostAuthRoleGrants
+	}
+	request.DataAccessIdentityProviderIDs = declared(currentModel.DataAccessIdentityProviderIds, prevDataAccess)
+	request.DomainAllowList = declared(currentModel.DomainAllowList, prevAllowList)
+	request.PostAuthRoleGrants = declared(currentModel.PostAuthRoleGrants, prevGrants)
+
+	config := new(connectedOrgConfig)
+	res, err := federation.Call(client, http.MethodPatch, connectedOrgConfigPath(*currentModel.FederationSettingsId, *currentModel.OrgId), request, config)
+	if err != nil {
+		return nil, res, err
+	}
+	return config, res, nil
+}
+
+// connectedOrgConfigToModel also reports the verified domains of the organization's identity provider, which
+// are the only domains that DomainAllowList accepts
+func connectedOrgConfigToModel(client *util.MongoDBClient, currentModel *Model, config *connectedOrgConfig) *Model {
+	readConnectedOrgConfig(currentModel, config)
+
+	settings, _, err := client.AtlasV2.FederatedAuthenticationApi.GetFederationSettings(context.Background(), *currentModel.OrgId).Execute()
+	if err != nil {
+		_, _ = log.Warnf("error getting federation settings of organization %s: %v", *currentModel.OrgId, err)
+		return currentModel
+	}
+	currentModel.FederatedDomains = settings.FederatedDomains
+	currentModel.IdentityProviderStatus = settings.IdentityProviderStatus
+	return currentModel
+}
+
+func readConnectedOrgConfig(model *Model, config *connectedOrgConfig) {
+	model.OrgId = config.OrgID
+	model.IdentityProviderId = nil
+	if util.IsStringPresent(config.IdentityProviderID) {
+		model.IdentityProviderId = config.IdentityProviderID
+	}
+	model.DomainRestrictionEnabled = config.DomainRestrictionEnabled
+	model.DataAccessIdentityProviderIds = flattenList(config.DataAccessIdentityProviderIDs)
+	model.DomainAllowList = flattenList(config.DomainAllowList)
+	model.PostAuthRoleGrants = flattenList(config.PostAuthRoleGrants)
+
+	model.UserConflicts = nil
+	for i := range config.UserConflicts {
+		model.UserConflicts = append(model.UserConflicts, FederatedUser{
+			EmailAddress: config.UserConflicts[i].EmailAddress,
+			FirstName:    config.UserConflicts[i].FirstName,
+			LastName:     config.UserConflicts[i].LastName,
+			UserId:       config.UserConflicts[i].UserID,
+		})
+	}
+}
+
+func flattenList(list *[]string) []string {
+	if list == nil || len(*list) == 0 {
+		return nil
+	}
+	return *list
+}
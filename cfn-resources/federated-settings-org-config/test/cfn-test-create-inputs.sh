@@ -0,0 +1,36 @@
+#!/usr/bin/env bash
+# cfn-test-create-inputs.sh
+#
+# This tool generates json files in the inputs/ for `cfn test`.
+#
+
+# NOTE: You need to set the Federation Settings Id, the Organization Id and the legacy (Okta) Id of a SAML identity
+#       provider of the federation in order to execute this resource.
+
+set -o nounset
+set -o pipefail
+WORDTOREMOVE="template."
+
+#set profile
+profile="federation"
+if [ ${MONGODB_ATLAS_PROFILE+x} ];then
+    echo "profile set to ${MONGODB_ATLAS_PROFILE}"
+    profile=${MONGODB_ATLAS_PROFILE}
+fi
+
+rm -rf inputs
+mkdir inputs
+
+cd "$(dirname "$0")" || exit
+for inputFile in inputs_*; do
+	outputFile=${inputFile//$WORDTOREMOVE/}
+	jq --arg org "$ATLAS_ORG_ID" \
+		--arg FederationSettingsId "$ATLAS_FEDERATED_SETTINGS_ID" \
+		--arg idp "$ATLAS_FEDERATED_IDP_ID" \
+		--arg profile "$profile" \
+		'.Profile?|=$profile | .FederationSettingsId?|=$FederationSettingsId | .OrgId?|=$org | .IdentityProviderId?|=$idp' \
+		"$inputFile" >"../inputs/$outputFile"
+done
+cd ..
+
+ls -l inputs
@@ -27,14 +27,15 @@ import (
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/profile"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/federation"
 	log "github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
 	progressevents "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
-	"go.mongodb.org/atlas/mongodbatlas"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
 
 var CreateRequiredFields = []string{constants.FederationSettingsID, constants.OrgID, constants.ExternalGroupName, constants.RoleAssignments}
-var ReadRequiredFields = []string{constants.FederationSettingsID, constants.ID, constants.OrgID}
+var ReadRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
 var UpdateRequiredFields = []string{constants.FederationSettingsID, constants.OrgID, constants.ID, constants.ExternalGroupName, constants.RoleAssignments}
 var DeleteRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
 var ListRequiredFields = []string{constants.FederationSettingsID, constants.OrgID}
@@ -43,6 +44,17 @@ const (
 	RoleAssignementShouldBeSet = "error creating federated settings org role mapping: RoleAssignments should be set when `Export` is set"
 )
 
+// roles that can be assigned on the organization and on its projects
+var (
+	orgRoles = map[string]bool{
+		"ORG_OWNER": true, "ORG_MEMBER": true, "ORG_GROUP_CREATOR": true, "ORG_BILLING_ADMIN": true, "ORG_BILLING_READ_ONLY": true, "ORG_READ_ONLY": true,
+	}
+	projectRoles = map[string]bool{
+		"GROUP_OWNER": true, "GROUP_CLUSTER_MANAGER": true, "GROUP_DATA_ACCESS_ADMIN": true, "GROUP_DATA_ACCESS_READ_WRITE": true,
+		"GROUP_DATA_ACCESS_READ_ONLY": true, "GROUP_READ_ONLY": true, "GROUP_SEARCH_INDEX_EDITOR": true,
+	}
+)
+
 func validateModel(fields []string, model *Model) *handler.ProgressEvent {
 	return validator.ValidateModel(fields, model)
 }
@@ -51,6 +63,31 @@ func setup() {
 	util.SetupLogger("mongodb-atlas-FederatedSettingsOrgRoleMapping")
 }
 
+// validateRoleAssignments checks that every assignment targets either the organization of the mapping or a
+// project, with a role of that scope
+func validateRoleAssignments(model *Model) error {
+	if len(model.RoleAssignments) == 0 {
+		return errors.New(RoleAssignementShouldBeSet)
+	}
+	for i := range model.RoleAssignments {
+		assignment := &model.RoleAssignments[i]
+		role := util.SafeString(assignment.Role)
+		hasOrg := util.IsStringPresent(assignment.OrgId)
+		hasProject := util.IsStringPresent(assignment.ProjectId)
+		switch {
+		case hasOrg == hasProject:
+			return fmt.Errorf("RoleAssignments entry %d must set exactly one of OrgId and ProjectId", i)
+		case hasOrg && !orgRoles[role]:
+			return fmt.Errorf("RoleAssignments entry %d: %s is not an organization role", i, role)
+		case hasOrg && *assignment.OrgId != *model.OrgId:
+			return fmt.Errorf("RoleAssignments entry %d: organization roles can only be assigned on the organization %s", i, *model.OrgId)
+		case hasProject && !projectRoles[role]:
+			return fmt.Errorf("RoleAssignments entry %d: %s is not a project role", i, role)
+		}
+	}
+	return nil
+}
+
 func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
 
@@ -61,37 +98,51 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if modelValidation != nil {
 		return *modelValidation, nil
 	}
+	if err := validateRoleAssignments(currentModel); err != nil {
+		return progressevents.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
 
 	// Create atlas client
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
 	}
-	client, pe := util.NewMongoDBClient(req, currentModel.Profile)
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
 	if pe != nil {
 		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
 		return *pe, nil
 	}
 
-	federationSettingsID := currentModel.FederationSettingsId
-	orgID := currentModel.OrgId
+	federationSettingsID := *currentModel.FederationSettingsId
+	orgID := *currentModel.OrgId
 
-	// preparing model request
-	requestBody, _, _ := modelToRoleMappingRequest(currentModel)
-	federatedSettingsOrganizationRoleMapping, resp, err := client.FederatedSettings.CreateRoleMapping(context.Background(), *federationSettingsID, *orgID, requestBody)
+	// group names are unique per organization, a mapping that already exists for the group is adopted
+	existing, resp, err := findRoleMappingByGroupName(client, federationSettingsID, orgID, *currentModel.ExternalGroupName)
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()), resp), nil
+	}
+
+	var roleMapping *admin.AuthFederationRoleMapping
+	if existing != nil {
+		_, _ = log.Debugf("adopting role mapping %s of group %s", util.SafeString(existing.Id), existing.ExternalGroupName)
+		roleMapping, resp, err = client.AtlasV2.FederatedAuthenticationApi.UpdateRoleMapping(context.Background(), federationSettingsID,
+			util.SafeString(existing.Id), orgID, newRoleMapping(currentModel)).Execute()
+	} else {
+		roleMapping, resp, err = client.AtlasV2.FederatedAuthenticationApi.CreateRoleMapping(context.Background(), federationSettingsID,
+			orgID, newRoleMapping(currentModel)).Execute()
+	}
 	if err != nil {
 		_, _ = log.Warnf("error creating federated settings: %s", err)
-		if resp.StatusCode == http.StatusBadRequest && strings.Contains(err.Error(), "DUPLICATE_ROLE_MAPPING") {
+		if resp != nil && resp.StatusCode == http.StatusBadRequest && strings.Contains(err.Error(), "DUPLICATE_ROLE_MAPPING") {
 			return progressevents.GetFailedEventByCode("Resource already exists",
 				cloudformation.HandlerErrorCodeAlreadyExists), nil
 		}
-		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
-			resp.Response), nil
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error creating resource : %s", err.Error()), resp), nil
 	}
-	currentModel.Id = &federatedSettingsOrganizationRoleMapping.ID
+
 	// Response
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
-		ResourceModel:   roleMappingToModel(*currentModel, federatedSettingsOrganizationRoleMapping),
+		ResourceModel:   roleMappingToModel(*currentModel, roleMapping),
 	}, nil
 }
 
@@ -110,27 +161,21 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
 	}
-	client, pe := util.NewMongoDBClient(req, currentModel.Profile)
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
 	if pe != nil {
 		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
 		return *pe, nil
 	}
 
-	federationSettingsID := currentModel.FederationSettingsId
-	orgID := currentModel.OrgId
-	roleMappingID := currentModel.Id
-
-	federatedSettingsOrganizationRoleMapping, resp, err := client.FederatedSettings.GetRoleMapping(context.Background(), *federationSettingsID, *orgID, *roleMappingID)
-	if err != nil {
-		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
-			resp.Response), nil
+	roleMapping, pe := getRoleMapping(client, currentModel)
+	if pe != nil {
+		return *pe, nil
 	}
-	_, _ = log.Debugf("Atlas Client %v", client)
 
 	// Response
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
-		ResourceModel:   roleMappingToModel(*currentModel, federatedSettingsOrganizationRoleMapping),
+		ResourceModel:   roleMappingToModel(*currentModel, roleMapping),
 	}, nil
 }
 
@@ -144,45 +189,49 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if modelValidation != nil {
 		return *modelValidation, nil
 	}
+	if err := validateRoleAssignments(currentModel); err != nil {
+		return progressevents.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
 
 	// Create atlas client
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
 	}
-	client, pe := util.NewMongoDBClient(req, currentModel.Profile)
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
 	if pe != nil {
 		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
 		return *pe, nil
 	}
 
-	federationSettingsID := currentModel.FederationSettingsId
-	orgID := currentModel.OrgId
-	roleMappingID := currentModel.Id
+	federationSettingsID := *currentModel.FederationSettingsId
+	orgID := *currentModel.OrgId
+	roleMappingID := *currentModel.Id
 
 	// Check if  already exist
-	if !isRoleMappingExists(currentModel, client) {
-		return progressevents.GetFailedEventByCode("Not Found", cloudformation.HandlerErrorCodeNotFound), nil
-	}
-	if (currentModel.RoleAssignments) == nil || len(currentModel.RoleAssignments) == 0 {
-		err := errors.New(RoleAssignementShouldBeSet)
-		_, _ = log.Warnf("Create - error: %+v", err)
-		return handler.ProgressEvent{
-			OperationStatus:  handler.Failed,
-			Message:          err.Error(),
-			HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-	}
-	// preparing model request
-	requestBody, _, _ := modelToRoleMappingRequest(currentModel)
-	federatedSettingsOrganizationRoleMapping, resp, err := client.FederatedSettings.UpdateRoleMapping(context.Background(), *federationSettingsID, *orgID, *roleMappingID, requestBody)
+	if _, pe = getRoleMapping(client, currentModel); pe != nil {
+		return *pe, nil
+	}
+
+	// renaming the group must not collide with the mapping of another group
+	other, resp, err := findRoleMappingByGroupName(client, federationSettingsID, orgID, *currentModel.ExternalGroupName)
 	if err != nil {
-		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error updating federated settings : %s", err.Error()),
-			resp.Response), nil
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()), resp), nil
+	}
+	if other != nil && util.SafeString(other.Id) != roleMappingID {
+		return progressevents.GetFailedEventByCode(fmt.Sprintf("role mapping %s already maps group %s", util.SafeString(other.Id), other.ExternalGroupName),
+			cloudformation.HandlerErrorCodeAlreadyExists), nil
+	}
+
+	roleMapping, resp, err := client.AtlasV2.FederatedAuthenticationApi.UpdateRoleMapping(context.Background(), federationSettingsID, roleMappingID, orgID,
+		newRoleMapping(currentModel)).Execute()
+	if err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error updating federated settings : %s", err.Error()), resp), nil
 	}
 	// Response
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Update Complete",
-		ResourceModel:   roleMappingToModel(*currentModel, federatedSettingsOrganizationRoleMapping),
+		ResourceModel:   roleMappingToModel(*currentModel, roleMapping),
 	}, nil
 }
 
@@ -201,23 +250,22 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
 	}
-	client, pe := util.NewMongoDBClient(req, currentModel.Profile)
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
 	if pe != nil {
 		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
 		return *pe, nil
 	}
 
 	// Check if  already exist
-	if !isRoleMappingExists(currentModel, client) {
-		return progressevents.GetFailedEventByCode("Not Found", cloudformation.HandlerErrorCodeNotFound), nil
+	roleMapping, pe := getRoleMapping(client, currentModel)
+	if pe != nil {
+		return *pe, nil
 	}
-	federationSettingsID := currentModel.FederationSettingsId
-	orgID := currentModel.OrgId
-	roleMappingID := currentModel.Id
-	resp, err := client.FederatedSettings.DeleteRoleMapping(context.Background(), *federationSettingsID, *orgID, *roleMappingID)
+
+	resp, err := client.AtlasV2.FederatedAuthenticationApi.DeleteRoleMapping(context.Background(), *currentModel.FederationSettingsId,
+		util.SafeString(roleMapping.Id), *currentModel.OrgId).Execute()
 	if err != nil {
-		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error deleting federated settings : %s", err.Error()),
-			resp.Response), nil
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error deleting federated settings : %s", err.Error()), resp), nil
 	}
 	// Response
 	return handler.ProgressEvent{
@@ -241,32 +289,20 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
 	}
-	client, pe := util.NewMongoDBClient(req, currentModel.Profile)
+	client, pe := util.NewAtlasClient(&req, currentModel.Profile)
 	if pe != nil {
 		_, _ = log.Warnf("CreateMongoDBClient error: %v", *pe)
 		return *pe, nil
 	}
 
-	federationSettingsID := currentModel.FederationSettingsId
-	orgID := currentModel.OrgId
-
-	listOptions := &mongodbatlas.ListOptions{ItemsPerPage: 100, PageNum: 1}
-	federatedSettingsOrganizationRoleMappings, resp, err := client.FederatedSettings.ListRoleMappings(context.Background(), *federationSettingsID, *orgID, listOptions)
+	roleMappings, resp, err := listRoleMappings(client, *currentModel.FederationSettingsId, *currentModel.OrgId)
 	if err != nil {
-		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting federated settings : %s", err.Error()),
-			resp.Response), nil
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting federated settings : %s", err.Error()), resp), nil
 	}
 
 	models := make([]interface{}, 0) // cfn test
-	for i := range federatedSettingsOrganizationRoleMappings.Results {
-		model := Model{}
-		model.Profile = currentModel.Profile
-		model.OrgId = currentModel.OrgId
-		model.FederationSettingsId = currentModel.FederationSettingsId
-		model.Id = &federatedSettingsOrganizationRoleMappings.Results[i].ID
-		model.ExternalGroupName = &federatedSettingsOrganizationRoleMappings.Results[i].ExternalGroupName
-		model.RoleAssignments = flattenRoleAssignments(federatedSettingsOrganizationRoleMappings.Results[i].RoleAssignments)
-		models = append(models, model)
+	for i := range roleMappings {
+		models = append(models, roleMappingToModel(*currentModel, &roleMappings[i]))
 	}
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
@@ -275,78 +311,99 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	}, nil
 }
 
-func modelToRoleMappingRequest(currentModel *Model) (*mongodbatlas.FederatedSettingsOrganizationRoleMapping, handler.ProgressEvent, error) {
-	// Atlas client
-	roleMappingRequest := &mongodbatlas.FederatedSettingsOrganizationRoleMapping{}
-	if currentModel.Id != nil {
-		roleMappingRequest.ID = *currentModel.Id
+// listRoleMappings pages through all the role mappings of the organization
+func listRoleMappings(client *util.MongoDBClient, federationSettingsID, orgID string) ([]admin.AuthFederationRoleMapping, *http.Response, error) {
+	path := federation.SettingsPath(federationSettingsID, "connectedOrgConfigs", orgID, "roleMappings")
+	return federation.ListAll[admin.AuthFederationRoleMapping](client, path, nil)
+}
+
+// findRoleMappingByGroupName returns the role mapping of the group, or nil if the group has none. Atlas
+// compares group names without regard to case.
+func findRoleMappingByGroupName(client *util.MongoDBClient, federationSettingsID, orgID, groupName string) (*admin.AuthFederationRoleMapping, *http.Response, error) {
+	roleMappings, resp, err := listRoleMappings(client, federationSettingsID, orgID)
+	if err != nil {
+		return nil, resp, err
+	}
+	for i := range roleMappings {
+		if strings.EqualFold(roleMappings[i].ExternalGroupName, groupName) {
+			return &roleMappings[i], resp, nil
+		}
+	}
+	return nil, resp, nil
+}
+
+// getRoleMapping reads the role mapping by Id, or by ExternalGroupName when the model has no Id
+func getRoleMapping(client *util.MongoDBClient, currentModel *Model) (*admin.AuthFederationRoleMapping, *handler.ProgressEvent) {
+	federationSettingsID := *currentModel.FederationSettingsId
+	orgID := *currentModel.OrgId
+
+	if util.IsStringPresent(currentModel.Id) {
+		roleMapping, resp, err := client.AtlasV2.FederatedAuthenticationApi.GetRoleMapping(context.Background(), federationSettingsID, *currentModel.Id, orgID).Execute()
+		if err != nil {
+			pe := progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()), resp)
+			return nil, &pe
+		}
+		return roleMapping, nil
+	}
+
+	if !util.IsStringPresent(currentModel.ExternalGroupName) {
+		pe := progressevents.GetFailedEventByCode("Id or ExternalGroupName is required", cloudformation.HandlerErrorCodeInvalidRequest)
+		return nil, &pe
+	}
+	roleMapping, resp, err := findRoleMappingByGroupName(client, federationSettingsID, orgID, *currentModel.ExternalGroupName)
+	if err != nil {
+		pe := progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()), resp)
+		return nil, &pe
 	}
-	if currentModel.ExternalGroupName != nil {
-		roleMappingRequest.ExternalGroupName = *currentModel.ExternalGroupName
+	if roleMapping == nil {
+		pe := progressevents.GetFailedEventByCode("Not Found", cloudformation.HandlerErrorCodeNotFound)
+		return nil, &pe
 	}
-	if currentModel.RoleAssignments != nil {
-		roleMappingRequest.RoleAssignments = expandRoleAssignments(currentModel.RoleAssignments)
+	return roleMapping, nil
+}
+
+func newRoleMapping(currentModel *Model) *admin.AuthFederationRoleMapping {
+	return &admin.AuthFederationRoleMapping{
+		ExternalGroupName: util.SafeString(currentModel.ExternalGroupName),
+		RoleAssignments:   expandRoleAssignments(currentModel.RoleAssignments),
 	}
-	return roleMappingRequest, handler.ProgressEvent{}, nil
 }
 
-func expandRoleAssignments(assignments []RoleAssignment) []*mongodbatlas.RoleAssignments {
-	roles := make([]*mongodbatlas.RoleAssignments, len(assignments))
+func expandRoleAssignments(assignments []RoleAssignment) []admin.RoleAssignment {
+	roles := make([]admin.RoleAssignment, len(assignments))
 	for i := range assignments {
-		var role string
-		if assignments[i].Role != nil {
-			role = *assignments[i].Role
-		}
-		var groupID string
-		if assignments[i].ProjectId != nil {
-			groupID = *assignments[i].ProjectId
-		}
-		var orgID string
-		if assignments[i].OrgId != nil {
-			orgID = *assignments[i].OrgId
-		}
-		roles[i] = &mongodbatlas.RoleAssignments{
-			Role:    role,
-			GroupID: groupID,
-			OrgID:   orgID,
+		roles[i] = admin.RoleAssignment{
+			Role:    assignments[i].Role,
+			GroupId: assignments[i].ProjectId,
+			OrgId:   assignments[i].OrgId,
 		}
 	}
-	fmt.Printf("roles: len %d %+v", len(roles), roles)
 	return roles
 }
 
-func roleMappingToModel(currentModel Model, roleMapping *mongodbatlas.FederatedSettingsOrganizationRoleMapping) *Model {
+func roleMappingToModel(currentModel Model, roleMapping *admin.AuthFederationRoleMapping) *Model {
 	out := &Model{
 		Profile:              currentModel.Profile,
 		FederationSettingsId: currentModel.FederationSettingsId,
 		OrgId:                currentModel.OrgId,
-		Id:                   &roleMapping.ID,
+		Id:                   roleMapping.Id,
 		ExternalGroupName:    &roleMapping.ExternalGroupName,
 		RoleAssignments:      flattenRoleAssignments(roleMapping.RoleAssignments),
 	}
 	return out
 }
 
-func flattenRoleAssignments(assignments []*mongodbatlas.RoleAssignments) []RoleAssignment {
-	roleAssignments := make([]RoleAssignment, 0)
-	for _, role := range assignments {
-		roleAssignments = append(roleAssignments, RoleAssignment{
-			Role:      &role.Role,
-			OrgId:     &role.OrgID,
-			ProjectId: &role.GroupID,
-		})
+func flattenRoleAssignments(assignments []admin.RoleAssignment) []RoleAssignment {
+	roleAssignments := make([]RoleAssignment, 0, len(assignments))
+	for i := range assignments {
+		assignment := RoleAssignment{Role: assignments[i].Role}
+		if util.IsStringPresent(assignments[i].GroupId) {
+			assignment.ProjectId = assignments[i].GroupId
+		}
+		if util.IsStringPresent(assignments[i].OrgId) {
+			assignment.OrgId = assignments[i].OrgId
+		}
+		roleAssignments = append(roleAssignments, assignment)
 	}
 	return roleAssignments
 }
-
-func isRoleMappingExists(currentModel *Model, client *mongodbatlas.Client) bool {
-	var isExists bool
-	fedSettingsConnectedOrg, _, err := client.FederatedSettings.GetRoleMapping(context.Background(), *currentModel.FederationSettingsId, *currentModel.OrgId, *currentModel.Id)
-	if err != nil {
-		return isExists
-	}
-	if fedSettingsConnectedOrg != nil {
-		isExists = true
-	}
-	return isExists
-}
@@ -0,0 +1,119 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/cidr"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	defaultPrefixLength = cidr.MinPrefixLength
+	itemsPerPage        = 500
+)
+
+// usedCIDRBlocks returns the CIDR blocks taken in the project: the Atlas CIDR blocks of the other AWS network
+// containers and the CIDR blocks of the VPCs peered with them
+func usedCIDRBlocks(client *util.MongoDBClient, projectID, containerID string) ([]string, *http.Response, error) {
+	var blocks []string
+	containers, resp, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.CloudProviderContainer, *http.Response, error) {
+		page, resp, err := client.AtlasV2.NetworkPeeringApi.ListPeeringContainerByCloudProviderWithParams(context.Background(),
+			&admin.ListPeeringContainerByCloudProviderApiParams{
+				GroupId:      projectID,
+				ProviderName: admin.PtrString(constants.AWS),
+				ItemsPerPage: util.Pointer(itemsPerPage),
+				PageNum:      util.Pointer(pageNum),
+			}).Execute()
+		if err != nil {
+			return nil, resp, err
+		}
+		return page.Results, resp, nil
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	for i := range containers {
+		if util.SafeString(containers[i].Id) != containerID && util.IsStringPresent(containers[i].AtlasCidrBlock) {
+			blocks = append(blocks, *containers[i].AtlasCidrBlock)
+		}
+	}
+
+	peers, resp, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.BaseNetworkPeeringConnectionSettings, *http.Response, error) {
+		page, resp, err := client.AtlasV2.NetworkPeeringApi.ListPeeringConnectionsWithParams(context.Background(),
+			&admin.ListPeeringConnectionsApiParams{
+				GroupId:      projectID,
+				ProviderName: admin.PtrString(constants.AWS),
+				ItemsPerPage: util.Pointer(itemsPerPage),
+				PageNum:      util.Pointer(pageNum),
+			}).Execute()
+		if err != nil {
+			return nil, resp, err
+		}
+		return page.Results, resp, nil
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	for i := range peers {
+		if util.IsStringPresent(peers[i].RouteTableCidrBlock) {
+			blocks = append(blocks, *peers[i].RouteTableCidrBlock)
+		}
+	}
+	return blocks, resp, nil
+}
+
+// resolveAtlasCIDRBlock checks the Atlas CIDR block of the model against the sizing rules and the blocks
+// taken in the project. Without a block, it picks the next free one from AtlasCidrSupernet.
+func resolveAtlasCIDRBlock(client *util.MongoDBClient, currentModel *Model, containerID string) *handler.ProgressEvent {
+	if !util.IsStringPresent(currentModel.AtlasCidrBlock) && !util.IsStringPresent(currentModel.AtlasCidrSupernet) {
+		return nil
+	}
+
+	used, resp, err := usedCIDRBlocks(client, *currentModel.ProjectId, containerID)
+	if err != nil {
+		pe := progressevent.GetFailedEventByResponse(err.Error(), resp)
+		return &pe
+	}
+
+	if util.IsStringPresent(currentModel.AtlasCidrBlock) {
+		if err = cidr.ValidateAtlasBlock(*currentModel.AtlasCidrBlock); err == nil {
+			err = cidr.CheckOverlap(*currentModel.AtlasCidrBlock, used)
+		}
+	} else {
+		prefixLength := defaultPrefixLength
+		if currentModel.AtlasCidrPrefixLength != nil {
+			prefixLength = *currentModel.AtlasCidrPrefixLength
+		}
+		var block string
+		if block, err = cidr.NextFree(*currentModel.AtlasCidrSupernet, prefixLength, used); err == nil {
+			_, _ = logger.Debugf("picked Atlas CIDR block %s from %s", block, *currentModel.AtlasCidrSupernet)
+			currentModel.AtlasCidrBlock = &block
+		}
+	}
+	if err != nil {
+		pe := progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+	return nil
+}
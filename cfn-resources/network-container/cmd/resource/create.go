@@ -28,7 +28,7 @@ import (
 	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
 
-var createRequiredFields = []string{constants.ProjectID, constants.RegionName, constants.AtlasCIDRBlock}
+var createRequiredFields = []string{constants.ProjectID, constants.RegionName}
 
 func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
@@ -46,6 +46,10 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
+	if pe := resolveAtlasCIDRBlock(client, currentModel, ""); pe != nil {
+		return *pe, nil
+	}
+
 	containerRequest := &admin.CloudProviderContainer{
 		ProviderName:   admin.PtrString(constants.AWS),
 		RegionName:     currentModel.RegionName,
@@ -103,8 +107,8 @@ func validateCreateModel(fields []string, model *Model) error {
 		return fmt.Errorf("`error creating network container: `%s` must be set", constants.RegionName)
 	}
 
-	if !util.IsStringPresent(model.AtlasCidrBlock) {
-		return fmt.Errorf("error creating network container: `%s` must be set", constants.AtlasCIDRBlock)
+	if !util.IsStringPresent(model.AtlasCidrBlock) && !util.IsStringPresent(model.AtlasCidrSupernet) {
+		return fmt.Errorf("error creating network container: `%s` or `AtlasCidrSupernet` must be set", constants.AtlasCIDRBlock)
 	}
 
 	if event := validator.ValidateModel(fields, model); event != nil {
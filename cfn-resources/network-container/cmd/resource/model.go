@@ -4,11 +4,13 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	ProjectId      *string `json:",omitempty"`
-	RegionName     *string `json:",omitempty"`
-	Provisioned    *bool   `json:",omitempty"`
-	VpcId          *string `json:",omitempty"`
-	AtlasCidrBlock *string `json:",omitempty"`
-	Id             *string `json:",omitempty"`
-	Profile        *string `json:",omitempty"`
+	ProjectId             *string `json:",omitempty"`
+	RegionName            *string `json:",omitempty"`
+	Provisioned           *bool   `json:",omitempty"`
+	VpcId                 *string `json:",omitempty"`
+	AtlasCidrBlock        *string `json:",omitempty"`
+	AtlasCidrSupernet     *string `json:",omitempty"`
+	AtlasCidrPrefixLength *int    `json:",omitempty"`
+	Id                    *string `json:",omitempty"`
+	Profile               *string `json:",omitempty"`
 }
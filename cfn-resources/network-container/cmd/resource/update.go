@@ -42,6 +42,13 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 
 	projectID := *currentModel.ProjectId
 	containerID := *currentModel.Id
+
+	// the supernet only picks the block on create, without a block updates keep the block of the container
+	if util.IsStringPresent(currentModel.AtlasCidrBlock) {
+		if pe := resolveAtlasCIDRBlock(client, currentModel, containerID); pe != nil {
+			return *pe, nil
+		}
+	}
 	containerRequest := &admin.CloudProviderContainer{}
 
 	CIDR := currentModel.AtlasCidrBlock
@@ -4,15 +4,19 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	ProjectId           *string `json:",omitempty"`
-	ContainerId         *string `json:",omitempty"`
-	AccepterRegionName  *string `json:",omitempty"`
-	AwsAccountId        *string `json:",omitempty"`
-	RouteTableCIDRBlock *string `json:",omitempty"`
-	VpcId               *string `json:",omitempty"`
-	ConnectionId        *string `json:",omitempty"`
-	ErrorStateName      *string `json:",omitempty"`
-	StatusName          *string `json:",omitempty"`
-	Id                  *string `json:",omitempty"`
-	Profile             *string `json:",omitempty"`
+	ProjectId           *string  `json:",omitempty"`
+	ContainerId         *string  `json:",omitempty"`
+	AccepterRegionName  *string  `json:",omitempty"`
+	AwsAccountId        *string  `json:",omitempty"`
+	RouteTableCIDRBlock *string  `json:",omitempty"`
+	VpcId               *string  `json:",omitempty"`
+	AutoAccept          *bool    `json:",omitempty"`
+	RouteTableIds       []string `json:",omitempty"`
+	EnableDnsResolution *bool    `json:",omitempty"`
+	AtlasCIDRBlock      *string  `json:",omitempty"`
+	ConnectionId        *string  `json:",omitempty"`
+	ErrorStateName      *string  `json:",omitempty"`
+	StatusName          *string  `json:",omitempty"`
+	Id                  *string  `json:",omitempty"`
+	Profile             *string  `json:",omitempty"`
 }
@@ -0,0 +1,177 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	awsutil "github.com/mongodb/mongodbatlas-cloudformation-resources/util/aws"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/cidr"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const itemsPerPage = 500
+
+// validateAutoAccept checks the peer VPC settings. They are applied with the credentials of the stack, so the
+// peer VPC must belong to the account of the stack.
+func validateAutoAccept(req handler.Request, currentModel *Model) *handler.ProgressEvent {
+	if !aws.BoolValue(currentModel.AutoAccept) {
+		if len(currentModel.RouteTableIds) > 0 || currentModel.EnableDnsResolution != nil {
+			pe := progressevent.GetFailedEventByCode("RouteTableIds and EnableDnsResolution require AutoAccept",
+				cloudformation.HandlerErrorCodeInvalidRequest)
+			return &pe
+		}
+		return nil
+	}
+
+	if util.IsStringPresent(currentModel.AwsAccountId) && *currentModel.AwsAccountId != req.RequestContext.AccountID {
+		pe := progressevent.GetFailedEventByCode(fmt.Sprintf("AutoAccept requires the peer VPC in the account of the stack %s, not %s",
+			req.RequestContext.AccountID, *currentModel.AwsAccountId), cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+	return nil
+}
+
+// validateRouteTableCIDRBlock rejects a peer VPC CIDR block that overlaps the Atlas CIDR blocks of the AWS network
+// containers of the project, Atlas couldn't route between the two VPCs
+func validateRouteTableCIDRBlock(client *util.MongoDBClient, currentModel *Model) *handler.ProgressEvent {
+	if !util.IsStringPresent(currentModel.RouteTableCIDRBlock) {
+		return nil
+	}
+	if _, err := cidr.Parse(*currentModel.RouteTableCIDRBlock); err != nil {
+		pe := progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+
+	containers, resp, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.CloudProviderContainer, *http.Response, error) {
+		page, resp, err := client.AtlasV2.NetworkPeeringApi.ListPeeringContainerByCloudProviderWithParams(context.Background(),
+			&admin.ListPeeringContainerByCloudProviderApiParams{
+				GroupId:      *currentModel.ProjectId,
+				ProviderName: admin.PtrString(constants.AWS),
+				ItemsPerPage: util.Pointer(itemsPerPage),
+				PageNum:      util.Pointer(pageNum),
+			}).Execute()
+		if err != nil {
+			return nil, resp, err
+		}
+		return page.Results, resp, nil
+	})
+	if err != nil {
+		pe := progressevent.GetFailedEventByResponse(err.Error(), resp)
+		return &pe
+	}
+	atlasBlocks := make([]string, 0, len(containers))
+	for i := range containers {
+		atlasBlocks = append(atlasBlocks, util.SafeString(containers[i].AtlasCidrBlock))
+	}
+	if err = cidr.CheckOverlap(*currentModel.RouteTableCIDRBlock, atlasBlocks); err != nil {
+		pe := progressevent.GetFailedEventByCode(fmt.Sprintf("RouteTableCIDRBlock: %s", err.Error()), cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+	return nil
+}
+
+// newPeerVpc returns the AWS side of the peering connection, with the Atlas CIDR block to route through it. The
+// peering is nil while Atlas hasn't returned the AWS VPC peering connection yet.
+func newPeerVpc(req handler.Request, client *util.MongoDBClient, currentModel *Model) (peering *awsutil.VpcPeering, atlasCIDRBlock string, pe *handler.ProgressEvent) {
+	projectID := *currentModel.ProjectId
+	peerResponse, resp, err := client.AtlasV2.NetworkPeeringApi.GetPeeringConnection(context.Background(), projectID, *currentModel.Id).Execute()
+	if err != nil {
+		fpe := progressevent.GetFailedEventByResponse(err.Error(), resp)
+		return nil, "", &fpe
+	}
+	if !util.IsStringPresent(peerResponse.ConnectionId) {
+		return nil, "", nil
+	}
+
+	container, resp, err := client.AtlasV2.NetworkPeeringApi.GetPeeringContainer(context.Background(), projectID, peerResponse.ContainerId).Execute()
+	if err != nil {
+		fpe := progressevent.GetFailedEventByResponse(err.Error(), resp)
+		return nil, "", &fpe
+	}
+
+	// the peer VPC is in the region of the Atlas VPC unless it was given
+	region := util.SafeString(container.RegionName)
+	if util.IsStringPresent(currentModel.AccepterRegionName) {
+		region = *currentModel.AccepterRegionName
+	}
+	return awsutil.NewVpcPeering(req, region, *peerResponse.ConnectionId), util.SafeString(container.AtlasCidrBlock), nil
+}
+
+// acceptPeering accepts the peering connection once Atlas asks for it. Once the connection is available, it sets
+// the DNS resolution of the peer VPC and routes the Atlas CIDR block through the connection in the route tables,
+// removing the routes of the route tables that are no longer listed. It reports false while Atlas hasn't returned
+// the AWS VPC peering connection yet.
+func acceptPeering(req handler.Request, client *util.MongoDBClient, prevModel, currentModel *Model, state string) (bool, *handler.ProgressEvent) {
+	peering, atlasCIDRBlock, pe := newPeerVpc(req, client, currentModel)
+	if pe != nil || peering == nil {
+		return false, pe
+	}
+	currentModel.AtlasCIDRBlock = aws.String(atlasCIDRBlock)
+
+	if state == StatusPendingAcceptance {
+		return true, peering.Accept()
+	}
+
+	if pe = peering.SetDNSResolution(aws.BoolValue(currentModel.EnableDnsResolution)); pe != nil {
+		return true, pe
+	}
+	if prevModel != nil && aws.BoolValue(prevModel.AutoAccept) {
+		if pe = peering.DeleteRoutes(atlasCIDRBlock, removedRouteTables(prevModel.RouteTableIds, currentModel.RouteTableIds)); pe != nil {
+			return true, pe
+		}
+	}
+	return true, peering.AddRoutes(atlasCIDRBlock, currentModel.RouteTableIds)
+}
+
+// deletePeerRoutes removes the routes that routedModel added to the route tables of the peer VPC. Without an AWS
+// VPC peering connection there is no route to remove.
+func deletePeerRoutes(req handler.Request, client *util.MongoDBClient, currentModel, routedModel *Model) *handler.ProgressEvent {
+	if !aws.BoolValue(routedModel.AutoAccept) || len(routedModel.RouteTableIds) == 0 {
+		return nil
+	}
+	peering, atlasCIDRBlock, pe := newPeerVpc(req, client, currentModel)
+	if pe != nil {
+		return pe
+	}
+	if peering == nil {
+		_, _ = logger.Warnf("network peering %s has no AWS VPC peering connection, no route to remove", *currentModel.Id)
+		return nil
+	}
+	return peering.DeleteRoutes(atlasCIDRBlock, routedModel.RouteTableIds)
+}
+
+func removedRouteTables(prev, current []string) []string {
+	kept := make(map[string]bool, len(current))
+	for _, id := range current {
+		kept[id] = true
+	}
+	var removed []string
+	for _, id := range prev {
+		if !kept[id] {
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
@@ -79,6 +79,9 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if errEvent := validateModel(CreateRequiredFields, currentModel); errEvent != nil {
 		return *errEvent, nil
 	}
+	if errEvent := validateAutoAccept(req, currentModel); errEvent != nil {
+		return *errEvent, nil
+	}
 
 	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
 	client, peErr := util.NewAtlasClient(&req, currentModel.Profile)
@@ -88,7 +91,11 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 
 	if _, ok := req.CallbackContext["stateName"]; ok {
 		currentModel.Id = aws.String(req.CallbackContext["id"].(string))
-		return validateCreationProcess(client, currentModel), nil
+		return validateCreationProcess(req, client, nil, currentModel, "Creating"), nil
+	}
+
+	if errEvent := validateRouteTableCIDRBlock(client, currentModel); errEvent != nil {
+		return *errEvent, nil
 	}
 
 	projectID := *currentModel.ProjectId
@@ -151,6 +158,14 @@ func Read(req handler.Request, prevModel, currentModel *Model) (handler.Progress
 	currentModel.Id = peerResponse.Id
 	currentModel.ConnectionId = peerResponse.ConnectionId
 	currentModel.ErrorStateName = peerResponse.ErrorStateName
+	if aws.BoolValue(currentModel.AutoAccept) {
+		var container *admin.CloudProviderContainer
+		container, resp, err = client.AtlasV2.NetworkPeeringApi.GetPeeringContainer(context.Background(), projectID, peerResponse.ContainerId).Execute()
+		if err != nil {
+			return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
+		}
+		currentModel.AtlasCIDRBlock = container.AtlasCidrBlock
+	}
 	if currentModel.ErrorStateName != nil {
 		currentModel.ErrorStateName = peerResponse.ErrorStateName
 	}
@@ -168,12 +183,20 @@ func Read(req handler.Request, prevModel, currentModel *Model) (handler.Progress
 // Update handles the Update event from the Cloudformation service.
 func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
+	if errEvent := validateAutoAccept(req, currentModel); errEvent != nil {
+		return *errEvent, nil
+	}
+
 	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
 	client, peErr := util.NewAtlasClient(&req, currentModel.Profile)
 	if peErr != nil {
 		return *peErr, nil
 	}
 
+	if _, ok := req.CallbackContext["stateName"]; ok {
+		return validateCreationProcess(req, client, prevModel, currentModel, "Updating"), nil
+	}
+
 	projectID := *currentModel.ProjectId
 	if currentModel.Id == nil || *currentModel.Profile == "" {
 		return handler.ProgressEvent{
@@ -182,6 +205,17 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			HandlerErrorCode: cloudformation.HandlerErrorCodeNotFound}, nil
 	}
 
+	if errEvent := validateRouteTableCIDRBlock(client, currentModel); errEvent != nil {
+		return *errEvent, nil
+	}
+
+	// the routes of the previous route tables are no longer managed once AutoAccept is turned off
+	if prevModel != nil && !aws.BoolValue(currentModel.AutoAccept) {
+		if errEvent := deletePeerRoutes(req, client, currentModel, prevModel); errEvent != nil {
+			return *errEvent, nil
+		}
+	}
+
 	peerID := *currentModel.Id
 	peerRequest := admin.BaseNetworkPeeringConnectionSettings{}
 
@@ -209,6 +243,16 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	}
 
 	currentModel.Id = peerResponse.Id
+	if aws.BoolValue(currentModel.AutoAccept) {
+		return progressevent.GetInProgressProgressEvent("Updating",
+			map[string]interface{}{
+				"stateName": util.SafeString(peerResponse.StatusName),
+			},
+			currentModel,
+			5,
+		), nil
+	}
+
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Update Complete",
@@ -233,6 +277,10 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return validateDeletionProcess(client, currentModel), nil
 	}
 
+	if errEvent := deletePeerRoutes(req, client, currentModel, currentModel); errEvent != nil {
+		return *errEvent, nil
+	}
+
 	projectID := *currentModel.ProjectId
 	peerID := *currentModel.Id
 	_, resp, err := client.AtlasV2.NetworkPeeringApi.DeletePeeringConnection(context.Background(), projectID, peerID).Execute()
@@ -313,25 +361,37 @@ func validateDeletionProcess(client *util.MongoDBClient, currentModel *Model) ha
 	)
 }
 
-func validateCreationProcess(client *util.MongoDBClient, currentModel *Model) handler.ProgressEvent {
+// validateCreationProcess waits for the peering connection to be requested. With AutoAccept, it also accepts
+// the connection on the AWS side and waits for it to be available.
+func validateCreationProcess(req handler.Request, client *util.MongoDBClient, prevModel, currentModel *Model, message string) handler.ProgressEvent {
 	state, err := getStatus(client, *currentModel.ProjectId, *currentModel.Id)
 	if err != nil {
 		return progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest)
 	}
 
 	if state == StatusPendingAcceptance || state == StatusAvailable {
-		return handler.ProgressEvent{
-			OperationStatus: handler.Success,
-			Message:         "Complete",
-			ResourceModel:   currentModel,
+		connected := true
+		if aws.BoolValue(currentModel.AutoAccept) {
+			var errEvent *handler.ProgressEvent
+			if connected, errEvent = acceptPeering(req, client, prevModel, currentModel, state); errEvent != nil {
+				return *errEvent
+			}
+		}
+		// without the AWS VPC peering connection yet, the handler keeps waiting
+		if connected && (state == StatusAvailable || !aws.BoolValue(currentModel.AutoAccept)) {
+			return handler.ProgressEvent{
+				OperationStatus: handler.Success,
+				Message:         "Complete",
+				ResourceModel:   currentModel,
+			}
 		}
 	}
 
 	if state == StatusFailed {
-		return progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInternalFailure)
+		return progressevent.GetFailedEventByCode(fmt.Sprintf("network peering %s failed", *currentModel.Id), cloudformation.HandlerErrorCodeInternalFailure)
 	}
 
-	return progressevent.GetInProgressProgressEvent("Creating",
+	return progressevent.GetInProgressProgressEvent(message,
 		map[string]interface{}{
 			"stateName": state,
 			"id":        &currentModel.Id,
@@ -4,14 +4,20 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	Name                *string `json:",omitempty"`
-	APIKey              *APIKey `json:",omitempty"`
-	FederatedSettingsId *string `json:",omitempty"`
-	OrgOwnerId          *string `json:",omitempty"`
-	Profile             *string `json:",omitempty"`
-	AwsSecretName       *string `json:",omitempty"`
-	OrgId               *string `json:",omitempty"`
-	IsDeleted           *bool   `json:",omitempty"`
+	Name                    *string `json:",omitempty"`
+	APIKey                  *APIKey `json:",omitempty"`
+	FederatedSettingsId     *string `json:",omitempty"`
+	OrgOwnerId              *string `json:",omitempty"`
+	Profile                 *string `json:",omitempty"`
+	AwsSecretName           *string `json:",omitempty"`
+	OrgId                   *string `json:",omitempty"`
+	IsDeleted               *bool   `json:",omitempty"`
+	ProfileName             *string `json:",omitempty"`
+	RegisteredProfile       *string `json:",omitempty"`
+	ApiAccessListRequired   *bool   `json:",omitempty"`
+	MultiFactorAuthRequired *bool   `json:",omitempty"`
+	RestrictEmployeeAccess  *bool   `json:",omitempty"`
+	GenAIFeaturesEnabled    *bool   `json:",omitempty"`
 }
 
 // APIKey is autogenerated from the json schema
@@ -22,7 +22,10 @@ import (
 	"time"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/profile"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
@@ -88,6 +91,20 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return handleError(response, constants.CREATE, err)
 	}
 
+	// The new organization key has no access list yet, it would lock itself out
+	if currentModel.ApiAccessListRequired != nil && *currentModel.ApiAccessListRequired {
+		return progress_events.GetFailedEventByCode("ApiAccessListRequired can't be enabled on Create, the new organization API key has no access list yet",
+			cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
+	// The profile secret must not exist yet, otherwise the new organization could not be registered
+	if util.IsStringPresent(currentModel.ProfileName) {
+		if _, _, err = secrets.Get(&req, profile.SecretNameWithPrefix(*currentModel.ProfileName)); err == nil {
+			return progress_events.GetFailedEventByCode(fmt.Sprintf("profile %s already exists", *currentModel.ProfileName),
+				cloudformation.HandlerErrorCodeAlreadyExists), nil
+		}
+	}
+
 	apikeyInputs := setAPIkeyInputs(currentModel)
 
 	// Set the roles from model
@@ -113,7 +130,8 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	// Read response
 	currentModel.OrgId = org.Organization.Id
 
-	// Save PrivateKey in AWS SecretManager
+	// Save PrivateKey in AWS SecretManager, the replaced version is restored if Create fails afterwards
+	previousSecretVersion := currentSecretVersion(&req, *currentModel.AwsSecretName)
 	secret := OrgProfile{OrgID: *currentModel.OrgId, PublicKey: *org.ApiKey.PublicKey, PrivateKey: *org.ApiKey.PrivateKey, BaseURL: atlas.Config.BaseURL}
 	_, _, err = secrets.PutSecret(&req, *currentModel.AwsSecretName, secret, currentModel.APIKey.Description)
 	if err != nil {
@@ -121,12 +139,86 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		response = &http.Response{StatusCode: http.StatusInternalServerError}
 		return handleError(response, constants.CREATE, err)
 	}
+
+	// The calling key is not a member of the new organization, so the settings are changed with the new organization key,
+	// which also deletes the organization again if Create fails from here on
+	orgClient, clientErr := util.NewAtlasV2ClientWithKeys(*org.ApiKey.PublicKey, *org.ApiKey.PrivateKey, atlas.Config.BaseURL)
+	if clientErr != nil {
+		response = &http.Response{StatusCode: http.StatusInternalServerError}
+		return handleError(response, constants.CREATE, clientErr)
+	}
+	if currentModel.hasSettings() {
+		settings, settingsResponse, settingsErr := updateOrgSettings(orgClient, *currentModel.OrgId, currentModel.settings())
+		if settingsErr != nil {
+			// CloudFormation doesn't delete a resource whose Create failed, so the organization is deleted here
+			deleteOrgAfterFailedCreate(orgClient, *currentModel.OrgId)
+			restoreSecretAfterFailedCreate(&req, *currentModel.AwsSecretName, previousSecretVersion)
+			return handleError(settingsResponse, constants.CREATE, settingsErr)
+		}
+		currentModel.readSettings(settings)
+	}
+
+	// Register the new organization key as a named profile usable by other resources
+	if util.IsStringPresent(currentModel.ProfileName) {
+		orgProfile := profile.Profile{PublicKey: *org.ApiKey.PublicKey, PrivateKey: *org.ApiKey.PrivateKey, BaseURL: atlas.Config.BaseURL}
+		description := fmt.Sprintf("MongoDB Atlas profile for organization %s", *currentModel.OrgId)
+		_, _, err = secrets.Create(&req, profile.SecretNameWithPrefix(*currentModel.ProfileName), orgProfile, &description)
+		if err != nil {
+			deleteOrgAfterFailedCreate(orgClient, *currentModel.OrgId)
+			restoreSecretAfterFailedCreate(&req, *currentModel.AwsSecretName, previousSecretVersion)
+			response = &http.Response{StatusCode: http.StatusInternalServerError}
+			return handleError(response, constants.CREATE, err)
+		}
+		currentModel.RegisteredProfile = currentModel.ProfileName
+	}
+
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Create Completed",
 		ResourceModel:   currentModel}, nil
 }
 
+// deleteOrgAfterFailedCreate deletes the organization that Create couldn't complete. The Delete API is synchronous and
+// slow, so it is only awaited as long as Delete does, a failure is logged for the organization to be deleted by hand.
+func deleteOrgAfterFailedCreate(atlas *util.MongoDBClient, orgID string) {
+	responseChan := make(chan DeleteResponse, 1)
+	go func() {
+		_, response, err := atlas.AtlasV2.OrganizationsApi.DeleteOrganization(context.Background(), orgID).Execute()
+		defer closeResponse(response)
+		responseChan <- DeleteResponse{Error: err, Response: response}
+	}()
+
+	select {
+	case responseMsg := <-responseChan:
+		if responseMsg.Error != nil {
+			_, _ = logger.Warnf("error deleting organization %s after the failed create, it must be deleted manually: %s", orgID, responseMsg.Error.Error())
+		}
+	case <-time.After(30 * time.Second):
+		_, _ = logger.Warnf("deletion of organization %s after the failed create is still in progress", orgID)
+	}
+}
+
+// currentSecretVersion returns the version of the secret labelled AWSCURRENT, or nil when the secret has no value yet
+func currentSecretVersion(req *handler.Request, secretName string) *string {
+	_, version, err := secrets.GetSecretVersion(req, secretName, nil, aws.String(secrets.StageCurrent))
+	if err != nil {
+		return nil
+	}
+	return version
+}
+
+// restoreSecretAfterFailedCreate labels the version that Create replaced as AWSCURRENT again, so that the secret
+// doesn't hold the key of the deleted organization
+func restoreSecretAfterFailedCreate(req *handler.Request, secretName string, previousVersion *string) {
+	if previousVersion == nil {
+		_, _ = logger.Warnf("secret %s had no value before the failed create, it still holds the key of the deleted organization", secretName)
+		return
+	}
+	if err := secrets.PromoteSecretVersion(req, secretName, *previousVersion); err != nil {
+		_, _ = logger.Warnf("error restoring secret %s after the failed create: %s", secretName, err.Error())
+	}
+}
+
 // Read handles the Read event from the Cloudformation service.
 func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
@@ -147,6 +239,15 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	if err != nil {
 		return handleError(response, constants.READ, err)
 	}
+	if util.IsStringPresent(currentModel.ProfileName) {
+		apiKeyUserDetails.RegisteredProfile = currentModel.ProfileName
+	}
+
+	settings, response, err := getOrgSettings(atlas, *currentModel.OrgId)
+	if err != nil {
+		return handleError(response, constants.READ, err)
+	}
+	apiKeyUserDetails.readSettings(settings)
 
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
@@ -167,6 +268,10 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
+	if err := validateSettingsNotUnset(prevModel, currentModel); err != nil {
+		return progress_events.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
+
 	atlasOrg := atlasSDK.AtlasOrganization{Id: currentModel.OrgId, Name: *currentModel.Name}
 	// Set the roles from model
 	renameOrganizationRequest := atlas.AtlasV2.OrganizationsApi.RenameOrganization(context.Background(), *currentModel.OrgId, &atlasOrg)
@@ -178,6 +283,18 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return handleError(response, constants.CREATE, err)
 	}
 
+	if currentModel.hasSettings() {
+		settings, settingsResponse, settingsErr := updateOrgSettings(atlas, *currentModel.OrgId, currentModel.settings())
+		if settingsErr != nil {
+			return handleError(settingsResponse, constants.UPDATE, settingsErr)
+		}
+		currentModel.readSettings(settings)
+	}
+
+	if util.IsStringPresent(currentModel.ProfileName) {
+		currentModel.RegisteredProfile = currentModel.ProfileName
+	}
+
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Update Completed",
@@ -200,7 +317,7 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 
 	// Callback
 	if _, idExists := req.CallbackContext[constants.StateName]; idExists {
-		return deleteCallback(atlas, currentModel)
+		return deleteCallback(&req, atlas, currentModel)
 	}
 
 	// Read before delete
@@ -240,6 +357,9 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		if responseMsg.Error != nil {
 			return handleError(responseMsg.Response, constants.DELETE, responseMsg.Error)
 		}
+		if pe := deleteRegisteredProfile(&req, currentModel); pe != nil {
+			return *pe, nil
+		}
 
 	case <-time.After(30 * time.Second):
 		// If the Delete is not completed in the above time,
@@ -261,12 +381,15 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		ResourceModel:   nil}, nil
 }
 
-func deleteCallback(atlas *util.MongoDBClient, currentModel *Model) (handler.ProgressEvent, error) {
+func deleteCallback(req *handler.Request, atlas *util.MongoDBClient, currentModel *Model) (handler.ProgressEvent, error) {
 	// Read before delete
 	org, response, err := currentModel.getOrgDetails(atlas, currentModel)
 	defer closeResponse(response)
 	if err != nil {
 		if response.StatusCode == http.StatusUnauthorized {
+			if pe := deleteRegisteredProfile(req, currentModel); pe != nil {
+				return *pe, nil
+			}
 			return handler.ProgressEvent{
 				OperationStatus: handler.Success,
 				Message:         DeleteCompleted,
@@ -276,6 +399,9 @@ func deleteCallback(atlas *util.MongoDBClient, currentModel *Model) (handler.Pro
 	}
 
 	if *org.IsDeleted {
+		if pe := deleteRegisteredProfile(req, currentModel); pe != nil {
+			return *pe, nil
+		}
 		return handler.ProgressEvent{
 			OperationStatus: handler.Success,
 			Message:         DeleteCompleted,
@@ -357,3 +483,21 @@ func setAPIkeyInputs(currentModel *Model) (apiKeyInput *atlasSDK.CreateAtlasOrga
 	}
 	return apiKeyInput
 }
+
+// deleteRegisteredProfile removes the profile secret registered on Create, if any
+func deleteRegisteredProfile(req *handler.Request, currentModel *Model) *handler.ProgressEvent {
+	if !util.IsStringPresent(currentModel.ProfileName) {
+		return nil
+	}
+	err := secrets.Delete(req, profile.SecretNameWithPrefix(*currentModel.ProfileName))
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+			return nil
+		}
+		pe := progress_events.GetFailedEventByCode(fmt.Sprintf("error deleting profile %s: %s", *currentModel.ProfileName, err.Error()),
+			cloudformation.HandlerErrorCodeServiceInternalError)
+		return &pe
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+)
+
+// orgSettings mirrors the organization settings of the Atlas Admin API. The atlas-sdk version in use
+// doesn't know genAIFeaturesEnabled yet, so the settings endpoint is called through util.CallAtlasV2API.
+type orgSettings struct {
+	APIAccessListRequired   *bool `json:"apiAccessListRequired,omitempty"`
+	MultiFactorAuthRequired *bool `json:"multiFactorAuthRequired,omitempty"`
+	RestrictEmployeeAccess  *bool `json:"restrictEmployeeAccess,omitempty"`
+	GenAIFeaturesEnabled    *bool `json:"genAIFeaturesEnabled,omitempty"`
+}
+
+func (model *Model) hasSettings() bool {
+	return model.ApiAccessListRequired != nil || model.MultiFactorAuthRequired != nil ||
+		model.RestrictEmployeeAccess != nil || model.GenAIFeaturesEnabled != nil
+}
+
+func (model *Model) settings() *orgSettings {
+	return &orgSettings{
+		APIAccessListRequired:   model.ApiAccessListRequired,
+		MultiFactorAuthRequired: model.MultiFactorAuthRequired,
+		RestrictEmployeeAccess:  model.RestrictEmployeeAccess,
+		GenAIFeaturesEnabled:    model.GenAIFeaturesEnabled,
+	}
+}
+
+func (model *Model) readSettings(settings *orgSettings) {
+	model.ApiAccessListRequired = settings.APIAccessListRequired
+	model.MultiFactorAuthRequired = settings.MultiFactorAuthRequired
+	model.RestrictEmployeeAccess = settings.RestrictEmployeeAccess
+	model.GenAIFeaturesEnabled = settings.GenAIFeaturesEnabled
+}
+
+// validateSettingsNotUnset rejects removing a setting from the template. Atlas always has a value for
+// every setting, so removing it would leave the organization in whatever state it was last set to.
+func validateSettingsNotUnset(prevModel, currentModel *Model) error {
+	if prevModel == nil {
+		return nil
+	}
+	unset := map[string]bool{
+		"ApiAccessListRequired":   prevModel.ApiAccessListRequired != nil && currentModel.ApiAccessListRequired == nil,
+		"MultiFactorAuthRequired": prevModel.MultiFactorAuthRequired != nil && currentModel.MultiFactorAuthRequired == nil,
+		"RestrictEmployeeAccess":  prevModel.RestrictEmployeeAccess != nil && currentModel.RestrictEmployeeAccess == nil,
+		"GenAIFeaturesEnabled":    prevModel.GenAIFeaturesEnabled != nil && currentModel.GenAIFeaturesEnabled == nil,
+	}
+	for _, name := range []string{"ApiAccessListRequired", "MultiFactorAuthRequired", "RestrictEmployeeAccess", "GenAIFeaturesEnabled"} {
+		if unset[name] {
+			return fmt.Errorf("%s can't be unset once it has been managed by this resource, set it explicitly to false instead", name)
+		}
+	}
+	return nil
+}
+
+func getOrgSettings(atlas *util.MongoDBClient, orgID string) (*orgSettings, *http.Response, error) {
+	return callOrgSettingsAPI(atlas, http.MethodGet, orgID, nil)
+}
+
+func updateOrgSettings(atlas *util.MongoDBClient, orgID string, settings *orgSettings) (*orgSettings, *http.Response, error) {
+	return callOrgSettingsAPI(atlas, http.MethodPatch, orgID, settings)
+}
+
+func callOrgSettingsAPI(atlas *util.MongoDBClient, method, orgID string, settings *orgSettings) (*orgSettings, *http.Response, error) {
+	var body any
+	if settings != nil {
+		body = settings
+	}
+	result := new(orgSettings)
+	response, err := util.CallAtlasV2API(atlas, method, fmt.Sprintf("/api/atlas/v2/orgs/%s/settings", orgID), body, result)
+	if err != nil {
+		return nil, response, err
+	}
+	return result, response, nil
+}
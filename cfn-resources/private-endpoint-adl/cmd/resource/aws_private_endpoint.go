@@ -0,0 +1,86 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	aws_utils "github.com/mongodb/mongodbatlas-cloudformation-resources/util/aws"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+)
+
+func (m *Model) createsAwsPrivateEndpoint() bool {
+	return aws.BoolValue(m.CreateAndAssignAWSPrivateEndpoint)
+}
+
+func (m *Model) awsPrivateEndpointConfig() *aws_utils.DataFederationEndpointConfig {
+	config := m.AwsPrivateEndpointConfigurationProperties
+	return &aws_utils.DataFederationEndpointConfig{
+		VpcID:               util.SafeString(config.VpcId),
+		SubnetIDs:           config.SubnetIds,
+		Region:              util.SafeString(config.Region),
+		EndpointServiceName: util.SafeString(config.EndpointServiceName),
+	}
+}
+
+// validateAwsPrivateEndpointProperties checks that the model either gives the ID of an existing interface endpoint
+// or the configuration of the one to create
+func (m *Model) validateAwsPrivateEndpointProperties() *handler.ProgressEvent {
+	if !m.createsAwsPrivateEndpoint() {
+		return validateAndDefaultRequest(RequiredFields, m)
+	}
+
+	var message string
+	switch {
+	case m.EndpointId != nil:
+		message = "EndpointId must be empty when CreateAndAssignAWSPrivateEndpoint is true"
+	case m.AwsPrivateEndpointConfigurationProperties == nil:
+		message = "AwsPrivateEndpointConfigurationProperties must be present when CreateAndAssignAWSPrivateEndpoint is true"
+	default:
+		if err := m.awsPrivateEndpointConfig().Validate(); err != nil {
+			message = fmt.Sprintf("AwsPrivateEndpointConfigurationProperties: %s", err.Error())
+		}
+	}
+	if message != "" {
+		pe := progressevent.GetFailedEventByCode(fmt.Sprintf("Validation failed: %s", message), cloudformation.HandlerErrorCodeInvalidRequest)
+		return &pe
+	}
+	return validateAndDefaultRequest(CreateRequiredFields, m)
+}
+
+// newCreateWorkflow creates the AWS interface endpoint, then adds it to the project
+func newCreateWorkflow(req handler.Request, client *util.MongoDBClient, currentModel *Model) *workflow.Workflow[aws_utils.DataFederationEndpointState] {
+	return aws_utils.NewDataFederationEndpointWorkflow(req, currentModel.awsPrivateEndpointConfig(), func(endpointID string) error {
+		currentModel.EndpointId = aws.String(endpointID)
+		if pe := addPrivateEndpoint(client, currentModel); pe != nil {
+			return workflow.EventError(pe)
+		}
+		return nil
+	})
+}
+
+// deleteAwsPrivateEndpoint deletes the interface endpoint that the resource created
+func deleteAwsPrivateEndpoint(req handler.Request, currentModel *Model) *handler.ProgressEvent {
+	config := currentModel.AwsPrivateEndpointConfigurationProperties
+	if !currentModel.createsAwsPrivateEndpoint() || config == nil || config.Region == nil {
+		return nil
+	}
+	return aws_utils.DeletePrivateEndpoint(req, []string{*currentModel.EndpointId}, *config.Region)
+}
@@ -4,10 +4,20 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	Profile    *string `json:",omitempty"`
-	ProjectId  *string `json:",omitempty"`
-	Comment    *string `json:",omitempty"`
-	EndpointId *string `json:",omitempty"`
-	Provider   *string `json:",omitempty"`
-	Type       *string `json:",omitempty"`
+	Profile                                   *string                   `json:",omitempty"`
+	ProjectId                                 *string                   `json:",omitempty"`
+	Comment                                   *string                   `json:",omitempty"`
+	EndpointId                                *string                   `json:",omitempty"`
+	Provider                                  *string                   `json:",omitempty"`
+	Type                                      *string                   `json:",omitempty"`
+	CreateAndAssignAWSPrivateEndpoint         *bool                     `json:",omitempty"`
+	AwsPrivateEndpointConfigurationProperties *AwsPrivateEndpointConfig `json:",omitempty"`
+}
+
+// AwsPrivateEndpointConfig is autogenerated from the json schema
+type AwsPrivateEndpointConfig struct {
+	VpcId               *string  `json:",omitempty"`
+	SubnetIds           []string `json:",omitempty"`
+	Region              *string  `json:",omitempty"`
+	EndpointServiceName *string  `json:",omitempty"`
 }
@@ -30,6 +30,7 @@ import (
 )
 
 var RequiredFields = []string{constants.ProjectID, constants.EndpointID}
+var CreateRequiredFields = []string{constants.ProjectID}
 var ListRequiredFields = []string{constants.ProjectID}
 
 // function to validate inputs to all actions
@@ -50,7 +51,7 @@ func setup() {
 // Create handles the Create event from the Cloudformation service.
 func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
-	validationError := validateAndDefaultRequest(RequiredFields, currentModel)
+	validationError := currentModel.validateAwsPrivateEndpointProperties()
 	if validationError != nil {
 		return *validationError, nil
 	}
@@ -61,33 +62,46 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	alreadyExists, pe := resourceAlreadyExists(*client, *currentModel)
-	if pe != nil {
-		return *pe, nil
-	}
+	if currentModel.createsAwsPrivateEndpoint() {
+		if pe := newCreateWorkflow(req, client, currentModel).Run(req.CallbackContext, currentModel); pe != nil {
+			return *pe, nil
+		}
+	} else {
+		alreadyExists, pe := resourceAlreadyExists(*client, *currentModel)
+		if pe != nil {
+			return *pe, nil
+		}
 
-	if alreadyExists {
-		return progressevent.GetFailedEventByCode("resource Already exists", cloudformation.HandlerErrorCodeAlreadyExists), nil
-	}
+		if alreadyExists {
+			return progressevent.GetFailedEventByCode("resource Already exists", cloudformation.HandlerErrorCodeAlreadyExists), nil
+		}
 
-	ctx := context.Background()
+		if pe = addPrivateEndpoint(client, currentModel); pe != nil {
+			return *pe, nil
+		}
+	}
+	event := handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Created Private Link ADL",
+		ResourceModel:   currentModel,
+	}
+	return event, nil
+}
 
+// addPrivateEndpoint adds the interface endpoint of the model to the project
+func addPrivateEndpoint(client *util.MongoDBClient, currentModel *Model) *handler.ProgressEvent {
 	requestBody := admin.PrivateNetworkEndpointIdEntry{
 		Provider:   currentModel.Provider,
 		Type:       currentModel.Type,
 		EndpointId: *currentModel.EndpointId,
 		Comment:    currentModel.Comment,
 	}
-	_, resp, err := client.AtlasV2.DataFederationApi.CreateDataFederationPrivateEndpoint(ctx, *currentModel.ProjectId, &requestBody).Execute()
+	_, resp, err := client.AtlasV2.DataFederationApi.CreateDataFederationPrivateEndpoint(context.Background(), *currentModel.ProjectId, &requestBody).Execute()
 	if err != nil {
-		return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
-	}
-	event := handler.ProgressEvent{
-		OperationStatus: handler.Success,
-		Message:         "Created Private Link ADL",
-		ResourceModel:   currentModel,
+		pe := progressevent.GetFailedEventByResponse(err.Error(), resp)
+		return &pe
 	}
-	return event, nil
+	return nil
 }
 
 func resourceAlreadyExists(client util.MongoDBClient, currentModel Model) (bool, *handler.ProgressEvent) {
@@ -164,6 +178,9 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if err != nil {
 		return progressevent.GetFailedEventByResponse(err.Error(), resp), nil
 	}
+	if pe := deleteAwsPrivateEndpoint(req, currentModel); pe != nil {
+		return *pe, nil
+	}
 	event := handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "delete data lake endpoint",
@@ -0,0 +1,119 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	itemsPerPage = 500
+
+	clusterTypeReplicaSet = "REPLICASET"
+)
+
+// affectedCluster is a cluster whose private endpoint connection strings change with the regionalized mode, with
+// its connection strings before the change and, once the cluster is back to IDLE, after the change
+type affectedCluster struct {
+	Name                 string
+	ConnectionStrings    []string
+	NewConnectionStrings []string `json:",omitempty"`
+}
+
+// affectedClusters returns the clusters of the project that use private endpoints and are sharded or span several
+// regions. Atlas gives such clusters one connection string per region in regionalized mode.
+func affectedClusters(client *util.MongoDBClient, projectID string) ([]affectedCluster, *http.Response, error) {
+	clusters, response, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.AdvancedClusterDescription, *http.Response, error) {
+		page, response, err := client.AtlasV2.ClustersApi.ListClustersWithParams(context.Background(), &admin.ListClustersApiParams{
+			GroupId:      projectID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, response, err
+		}
+		return page.Results, response, nil
+	})
+	if err != nil {
+		return nil, response, err
+	}
+
+	var affected []affectedCluster
+	for i := range clusters {
+		cluster := &clusters[i]
+		connectionStrings := privateEndpointConnectionStrings(cluster)
+		if len(connectionStrings) == 0 {
+			continue
+		}
+		if util.SafeString(cluster.ClusterType) != clusterTypeReplicaSet || len(util.ClusterRegions(cluster, "")) > 1 {
+			affected = append(affected, affectedCluster{Name: util.SafeString(cluster.Name), ConnectionStrings: connectionStrings})
+		}
+	}
+	return affected, response, nil
+}
+
+// isIdle reports whether the cluster is back to IDLE, and then records its new connection strings. A cluster that
+// was deleted meanwhile is done.
+func (c *affectedCluster) isIdle(client *util.MongoDBClient, projectID string) (bool, *http.Response, error) {
+	cluster, response, err := client.AtlasV2.ClustersApi.GetCluster(context.Background(), projectID, c.Name).Execute()
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return true, response, nil
+		}
+		return false, response, err
+	}
+	if util.SafeString(cluster.StateName) != constants.IdleState {
+		return false, response, nil
+	}
+	c.NewConnectionStrings = privateEndpointConnectionStrings(cluster)
+	return true, response, nil
+}
+
+func privateEndpointConnectionStrings(cluster *admin.AdvancedClusterDescription) []string {
+	if cluster.ConnectionStrings == nil {
+		return nil
+	}
+	var connectionStrings []string
+	for i := range cluster.ConnectionStrings.PrivateEndpoint {
+		pe := &cluster.ConnectionStrings.PrivateEndpoint[i]
+		if util.IsStringPresent(pe.SrvConnectionString) {
+			connectionStrings = append(connectionStrings, *pe.SrvConnectionString)
+		} else if util.IsStringPresent(pe.ConnectionString) {
+			connectionStrings = append(connectionStrings, *pe.ConnectionString)
+		}
+	}
+	return connectionStrings
+}
+
+// describeClusters lists the clusters with their connection strings, and the new ones when they are known
+func describeClusters(clusters []affectedCluster) string {
+	descriptions := make([]string, len(clusters))
+	for i := range clusters {
+		c := &clusters[i]
+		descriptions[i] = fmt.Sprintf("%s (%s", c.Name, strings.Join(c.ConnectionStrings, ", "))
+		if c.NewConnectionStrings != nil {
+			descriptions[i] += fmt.Sprintf(" -> %s", strings.Join(c.NewConnectionStrings, ", "))
+		}
+		descriptions[i] += ")"
+	}
+	return strings.Join(descriptions, "; ")
+}
@@ -4,6 +4,7 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	ProjectId *string `json:",omitempty"`
-	Profile   *string `json:",omitempty"`
+	ProjectId                    *string `json:",omitempty"`
+	Profile                      *string `json:",omitempty"`
+	AllowConnectionStringChanges *bool   `json:",omitempty"`
 }
@@ -25,12 +25,11 @@ import (
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/validator"
-	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
 
 var CreateRequiredFields = []string{constants.ProjectID}
 var ReadRequiredFields = []string{constants.ProjectID}
-var UpdateRequiredFields []string
+var UpdateRequiredFields = []string{constants.ProjectID}
 var DeleteRequiredFields = []string{constants.ProjectID}
 var ListRequiredFields = []string{constants.ProjectID}
 
@@ -51,13 +50,16 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	if isRegModeSettingExists(currentModel, mongodbClient) {
-		return progressevent.GetFailedEventByCode(fmt.Sprintf("Regionalized Setting for Private Endpoint already enabled for : %s", *currentModel.ProjectId),
-			cloudformation.HandlerErrorCodeAlreadyExists), nil
+	var changed []affectedCluster
+	if pe := newToggleWorkflow(mongodbClient, currentModel, true, &changed).Run(req.CallbackContext, currentModel); pe != nil {
+		return *pe, nil
 	}
 
-	// API call to Add Regional Mode for Private Endpoint
-	return resourcePrivateEndpointRegionalModeUpdate(currentModel, mongodbClient, true)
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         completeMessage("Create Complete", changed),
+		ResourceModel:   newResponseModel(*currentModel),
+	}, nil
 }
 
 // Read handles the Read event from the Cloudformation service.
@@ -90,11 +92,20 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	}, nil
 }
 
-// Update handles the Update event from the Cloudformation service.
+// Update handles the Update event from the Cloudformation service. Only AllowConnectionStringChanges can change,
+// and it's only used when the setting is turned on or off.
 func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
-	// Not implemented, return an empty handler.ProgressEvent
-	// and an error
-	return handler.ProgressEvent{}, errors.New("not implemented: Update")
+	setup()
+
+	if errEvent := validator.ValidateModel(UpdateRequiredFields, currentModel); errEvent != nil {
+		return *errEvent, nil
+	}
+
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Update Complete",
+		ResourceModel:   newResponseModel(*currentModel),
+	}, nil
 }
 
 // Delete handles the Delete event from the Cloudformation service.
@@ -111,21 +122,15 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	if isRegModeSettingExists(currentModel, mongodbClient) {
-		events, err := resourcePrivateEndpointRegionalModeUpdate(currentModel, mongodbClient, false)
-		if err != nil {
-			return progressevent.GetFailedEventByCode(fmt.Sprintf("Error in disabling regionalized mode for private endpoint for Project : %s", *currentModel.ProjectId),
-				events.HandlerErrorCode), nil
-		}
-
-		return handler.ProgressEvent{
-			OperationStatus: handler.Success,
-			Message:         "Delete Complete",
-		}, nil
+	var changed []affectedCluster
+	if pe := newToggleWorkflow(mongodbClient, currentModel, false, &changed).Run(req.CallbackContext, currentModel); pe != nil {
+		return *pe, nil
 	}
 
-	return progressevent.GetFailedEventByCode(fmt.Sprintf("Error in disabling regionalized mode for private endpoint for Project : %s", *currentModel.ProjectId),
-		cloudformation.HandlerErrorCodeNotFound), nil
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         completeMessage("Delete Complete", changed),
+	}, nil
 }
 
 // List handles the List event from the Cloudformation service.
@@ -135,35 +140,11 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	return handler.ProgressEvent{}, errors.New("not implemented: List")
 }
 
-func resourcePrivateEndpointRegionalModeUpdate(currentModel *Model, client *util.MongoDBClient, enabled bool) (handler.ProgressEvent, error) {
-	_, response, err := client.AtlasV2.PrivateEndpointServicesApi.ToggleRegionalizedPrivateEndpointSetting(context.Background(), *currentModel.ProjectId,
-		&admin.ProjectSettingItem{
-			Enabled: enabled,
-		}).Execute()
-	if err != nil {
-		return progressevent.GetFailedEventByResponse(err.Error(), response), nil
-	}
-
-	return handler.ProgressEvent{
-		OperationStatus: handler.Success,
-		Message:         "Create Complete",
-		ResourceModel:   newResponseModel(*currentModel),
-	}, nil
-}
-
-func isRegModeSettingExists(currentModel *Model, client *util.MongoDBClient) bool {
-	regModeSetting, _, err := client.AtlasV2.PrivateEndpointServicesApi.GetRegionalizedPrivateEndpointSetting(context.Background(), *currentModel.ProjectId).Execute()
-	if err != nil {
-		return false
-	}
-
-	return regModeSetting.Enabled
-}
-
 func newResponseModel(currentModel Model) *Model {
 	out := &Model{
-		ProjectId: currentModel.ProjectId,
-		Profile:   currentModel.Profile,
+		ProjectId:                    currentModel.ProjectId,
+		Profile:                      currentModel.Profile,
+		AllowConnectionStringChanges: currentModel.AllowConnectionStringChanges,
 	}
 	return out
 }
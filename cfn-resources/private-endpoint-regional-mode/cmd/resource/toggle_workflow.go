@@ -0,0 +1,148 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	checkingClusters   = "CHECKING_CLUSTERS"
+	togglingSetting    = "TOGGLING_REGIONALIZED_MODE"
+	waitingForClusters = "WAITING_FOR_CLUSTERS"
+)
+
+// toggleState is the state of the change of the setting carried between the callbacks
+type toggleState struct {
+	Clusters []affectedCluster
+	Idle     []bool
+	Toggled  bool
+}
+
+// newToggleWorkflow turns the regionalized mode on or off. It first lists the clusters whose connection strings
+// change, and refuses to turn the mode on unless AllowConnectionStringChanges is set, then changes the setting and
+// waits for these clusters to be back to IDLE. Turning the mode off is never refused, so that the stack can be
+// deleted, and the clusters are only logged. The clusters, with their new connection strings, are stored in changed once
+// the workflow is done.
+func newToggleWorkflow(client *util.MongoDBClient, currentModel *Model, enabled bool, changed *[]affectedCluster) *workflow.Workflow[toggleState] {
+	projectID := *currentModel.ProjectId
+	mode := "off"
+	if enabled {
+		mode = "on"
+	}
+
+	return &workflow.Workflow[toggleState]{
+		Steps: []workflow.Step[toggleState]{
+			{
+				Name: checkingClusters,
+				Run: func(state *toggleState) (bool, error) {
+					setting, response, err := client.AtlasV2.PrivateEndpointServicesApi.GetRegionalizedPrivateEndpointSetting(context.Background(), projectID).Execute()
+					if err != nil {
+						return false, responseError(err, response)
+					}
+					if setting.Enabled == enabled {
+						if enabled {
+							return false, workflow.Fail(cloudformation.HandlerErrorCodeAlreadyExists,
+								fmt.Errorf("regionalized setting for private endpoint already enabled for project %s", projectID))
+						}
+						return false, workflow.Fail(cloudformation.HandlerErrorCodeNotFound,
+							fmt.Errorf("regionalized setting for private endpoint not found for project %s", projectID))
+					}
+
+					clusters, response, err := affectedClusters(client, projectID)
+					if err != nil {
+						return false, responseError(err, response)
+					}
+					if len(clusters) > 0 {
+						if enabled && !aws.BoolValue(currentModel.AllowConnectionStringChanges) {
+							return false, workflow.Fail(cloudformation.HandlerErrorCodeInvalidRequest,
+								fmt.Errorf("turning the regionalized mode %s changes the private endpoint connection strings of the clusters %s, set AllowConnectionStringChanges to proceed",
+									mode, describeClusters(clusters)))
+						}
+						_, _ = logger.Warnf("turning the regionalized mode %s changes the private endpoint connection strings of the clusters %s", mode, describeClusters(clusters))
+					}
+					state.Clusters = clusters
+					state.Idle = make([]bool, len(clusters))
+					return true, nil
+				},
+			},
+			{
+				Name: togglingSetting,
+				Run: func(state *toggleState) (bool, error) {
+					if state.Toggled {
+						return true, nil
+					}
+					_, response, err := client.AtlasV2.PrivateEndpointServicesApi.ToggleRegionalizedPrivateEndpointSetting(context.Background(), projectID,
+						&admin.ProjectSettingItem{
+							Enabled: enabled,
+						}).Execute()
+					if err != nil {
+						return false, responseError(err, response)
+					}
+					// the clusters are checked from the next callback on, so that their update has started
+					state.Toggled = true
+					return false, nil
+				},
+			},
+			{
+				Name: waitingForClusters,
+				Run: func(state *toggleState) (bool, error) {
+					done := true
+					for i := range state.Clusters {
+						if state.Idle[i] {
+							continue
+						}
+						idle, response, err := state.Clusters[i].isIdle(client, projectID)
+						if err != nil {
+							return false, responseError(err, response)
+						}
+						state.Idle[i] = idle
+						done = done && idle
+					}
+					if done {
+						*changed = state.Clusters
+					}
+					return done, nil
+				},
+			},
+		},
+	}
+}
+
+// completeMessage adds the changes of the connection strings to the message of the handler
+func completeMessage(message string, changed []affectedCluster) string {
+	if len(changed) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s, private endpoint connection strings changed: %s", message, describeClusters(changed))
+}
+
+func responseError(err error, response *http.Response) error {
+	if response == nil {
+		return err
+	}
+	pe := progressevent.GetFailedEventByResponse(err.Error(), response)
+	return workflow.EventError(&pe)
+}
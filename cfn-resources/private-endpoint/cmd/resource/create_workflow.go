@@ -0,0 +1,100 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	resource_constats "github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/privateendpoint"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/privateendpointservice"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// createState is the state of the creation carried between the callbacks. PrivateEndpoints follows the order of
+// the PrivateEndpoints of the model, each entry progresses on its own.
+type createState struct {
+	EndpointServiceID   string
+	EndpointServiceName string
+	PrivateEndpoints    []privateendpoint.AtlasPrivateEndpointCallBack
+}
+
+// newCreateWorkflow creates the endpoint service, waits for it to be available, then creates the private endpoints.
+// When a step fails, the interface endpoints and then, once Atlas released them, the endpoint service created so far
+// are removed.
+func newCreateWorkflow(req handler.Request, mongodbClient *mongodbatlas.Client, currentModel *Model) *workflow.Workflow[createState] {
+	return &workflow.Workflow[createState]{
+		Steps: []workflow.Step[createState]{
+			{
+				Name: string(resource_constats.CreatingPrivateEndpointService),
+				Run: func(state *createState) (bool, error) {
+					if state.EndpointServiceID == "" {
+						id, err := privateendpointservice.Create(mongodbClient, currentModel.cloudProvider(), *currentModel.Region, *currentModel.GroupId)
+						if err != nil {
+							return false, err
+						}
+						state.EndpointServiceID = id
+						currentModel.Id = &state.EndpointServiceID
+						return false, nil
+					}
+
+					peConnection, err := privateendpointservice.GetAvailable(mongodbClient, currentModel.cloudProvider(), *currentModel.GroupId, state.EndpointServiceID)
+					if err != nil || peConnection == nil {
+						return false, err
+					}
+					state.EndpointServiceName = peConnection.EndpointServiceName
+					state.PrivateEndpoints = make([]privateendpoint.AtlasPrivateEndpointCallBack, len(currentModel.PrivateEndpoints))
+					return true, nil
+				},
+				Rollback: func(state *createState) error {
+					if state.EndpointServiceID == "" {
+						return nil
+					}
+					if err := privateendpointservice.Delete(mongodbClient, currentModel.cloudProvider(), *currentModel.GroupId, state.EndpointServiceID); err != nil {
+						return fmt.Errorf("endpoint service %s was not deleted: %w", state.EndpointServiceID, err)
+					}
+					return nil
+				},
+			},
+			{
+				Name: string(resource_constats.CreatingPrivateEndpoint),
+				Run: func(state *createState) (bool, error) {
+					currentModel.Id = &state.EndpointServiceID
+					currentModel.EndpointServiceName = &state.EndpointServiceName
+					done, err := advanceEndpoints(req, mongodbClient, currentModel, state)
+					if !done || err != nil {
+						return false, err
+					}
+
+					interfaceEndpoints := make([]string, len(state.PrivateEndpoints))
+					for i := range state.PrivateEndpoints {
+						interfaceEndpoints[i] = state.PrivateEndpoints[i].InterfaceEndpointID
+					}
+					currentModel.InterfaceEndpoints = interfaceEndpoints
+					return true, nil
+				},
+				Rollback: func(state *createState) error {
+					return releaseEndpoints(req, mongodbClient, currentModel, state)
+				},
+				// Atlas deletes the endpoints asynchronously, and refuses to delete an endpoint service that still has some
+				WaitRollback: func(state *createState) (bool, error) {
+					return endpointsReleased(mongodbClient, currentModel, state)
+				},
+			},
+		},
+	}
+}
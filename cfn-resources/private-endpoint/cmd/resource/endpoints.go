@@ -0,0 +1,276 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/awsvpcendpoint"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/privateendpoint"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	progress_events "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+	"go.mongodb.org/atlas/mongodbatlas"
+)
+
+// errEndpointFailed marks the failures that retrying can't fix
+var errEndpointFailed = errors.New("private endpoint failed")
+
+// cloudProvider returns the provider of the endpoint service, AWS when the model doesn't set one
+func (m *Model) cloudProvider() string {
+	if util.IsStringPresent(m.CloudProvider) {
+		return *m.CloudProvider
+	}
+	return privateendpoint.ProviderAWS
+}
+
+func validatePrivateEndpoints(model *Model) *handler.ProgressEvent {
+	provider := model.cloudProvider()
+	for i := range model.PrivateEndpoints {
+		ep := &model.PrivateEndpoints[i]
+		var missing string
+		switch provider {
+		case privateendpoint.ProviderAzure:
+			if !util.IsStringPresent(ep.AzurePrivateEndpointResourceId) || !util.IsStringPresent(ep.AzurePrivateEndpointIpAddress) {
+				missing = "AzurePrivateEndpointResourceId and AzurePrivateEndpointIpAddress"
+			}
+		case privateendpoint.ProviderGCP:
+			if !util.IsStringPresent(ep.GcpProjectId) || !util.IsStringPresent(ep.GcpEndpointGroupName) || len(ep.GcpForwardingRules) == 0 {
+				missing = "GcpProjectId, GcpEndpointGroupName and GcpForwardingRules"
+			}
+		default:
+			if !util.IsStringPresent(ep.VpcId) || len(ep.SubnetIds) == 0 {
+				missing = "VpcId and SubnetIds"
+			}
+		}
+		if missing != "" {
+			pe := progress_events.GetFailedEventByCode(fmt.Sprintf("PrivateEndpoints entry %d of a %s endpoint service requires %s", i, provider, missing),
+				cloudformation.HandlerErrorCodeInvalidRequest)
+			return &pe
+		}
+	}
+	return nil
+}
+
+// endpointID returns the ID under which Atlas knows the i-th Azure or GCP endpoint: the resource ID of the Azure
+// private endpoint or the name of the GCP endpoint group
+func (m *Model) endpointID(i int) string {
+	ep := m.PrivateEndpoints[i]
+	if m.cloudProvider() == privateendpoint.ProviderGCP {
+		return util.SafeString(ep.GcpEndpointGroupName)
+	}
+	return util.SafeString(ep.AzurePrivateEndpointResourceId)
+}
+
+// endpointName names the i-th endpoint in the messages, before its ID is known
+func (m *Model) endpointName(i int) string {
+	if m.cloudProvider() == privateendpoint.ProviderAWS {
+		return util.SafeString(m.PrivateEndpoints[i].VpcId)
+	}
+	return m.endpointID(i)
+}
+
+// newInterfaceEndpointConnection returns the request that adds the i-th endpoint to the endpoint service
+func (m *Model) newInterfaceEndpointConnection(i int, interfaceEndpointID string) *mongodbatlas.InterfaceEndpointConnection {
+	ep := m.PrivateEndpoints[i]
+	switch m.cloudProvider() {
+	case privateendpoint.ProviderAzure:
+		return &mongodbatlas.InterfaceEndpointConnection{
+			ID:                       interfaceEndpointID,
+			PrivateEndpointIPAddress: util.SafeString(ep.AzurePrivateEndpointIpAddress),
+		}
+	case privateendpoint.ProviderGCP:
+		endpoints := make([]*mongodbatlas.GCPEndpoint, len(ep.GcpForwardingRules))
+		for j, rule := range ep.GcpForwardingRules {
+			endpoints[j] = &mongodbatlas.GCPEndpoint{
+				EndpointName: util.SafeString(rule.EndpointName),
+				IPAddress:    util.SafeString(rule.IpAddress),
+			}
+		}
+		return &mongodbatlas.InterfaceEndpointConnection{
+			EndpointGroupName: interfaceEndpointID,
+			GCPProjectID:      util.SafeString(ep.GcpProjectId),
+			Endpoints:         endpoints,
+		}
+	default:
+		return &mongodbatlas.InterfaceEndpointConnection{ID: interfaceEndpointID}
+	}
+}
+
+func (m *Model) newAwsPrivateEndpointInput(i int) awsvpcendpoint.AwsPrivateEndpointInput {
+	ep := m.PrivateEndpoints[i]
+	return awsvpcendpoint.AwsPrivateEndpointInput{
+		VpcID:             *ep.VpcId,
+		SubnetIDs:         ep.SubnetIds,
+		SecurityGroupIDs:  ep.SecurityGroupIds,
+		PrivateDNSEnabled: aws.BoolValue(ep.PrivateDnsEnabled),
+		RoleArn:           util.SafeString(ep.RoleArn),
+	}
+}
+
+// clientToken identifies the interface endpoint of the i-th VPC of the endpoint service across retries
+func clientToken(endpointServiceID string, i int) string {
+	return fmt.Sprintf("%s-%d", endpointServiceID, i)
+}
+
+// advanceEndpoints runs the next step of every private endpoint and reports whether all of them are available.
+// The other endpoints keep progressing when one fails, the errors are then returned together so that the workflow
+// retries the step.
+func advanceEndpoints(req handler.Request, mongodbClient *mongodbatlas.Client, currentModel *Model, state *createState) (bool, error) {
+	if len(state.PrivateEndpoints) != len(currentModel.PrivateEndpoints) {
+		return false, workflow.Fail(cloudformation.HandlerErrorCodeInvalidRequest, errors.New("PrivateEndpoints changed while they were being created"))
+	}
+
+	provider := currentModel.cloudProvider()
+	completed := true
+	var errs []error
+	for i := range state.PrivateEndpoints {
+		endpoint := &state.PrivateEndpoints[i]
+		if err := advanceEndpoint(req, mongodbClient, currentModel, state, i); err != nil {
+			err = fmt.Errorf("private endpoint of %s: %w", currentModel.endpointName(i), err)
+			if errors.Is(err, errEndpointFailed) {
+				return false, workflow.Fail(cloudformation.HandlerErrorCodeGeneralServiceException, err)
+			}
+			errs = append(errs, err)
+		}
+
+		if provider == privateendpoint.ProviderAWS && endpoint.InterfaceEndpointID != "" {
+			currentModel.PrivateEndpoints[i].InterfaceEndpointId = aws.String(endpoint.InterfaceEndpointID)
+		}
+		if endpoint.IsAdded() {
+			if provider == privateendpoint.ProviderAWS {
+				currentModel.PrivateEndpoints[i].AWSPrivateEndpointStatus = aws.String(endpoint.Status)
+			} else {
+				currentModel.PrivateEndpoints[i].AtlasPrivateEndpointStatus = aws.String(endpoint.Status)
+			}
+		}
+		if endpoint.Status != privateendpoint.StatusAvailable {
+			completed = false
+		}
+	}
+	return completed, errors.Join(errs...)
+}
+
+// advanceEndpoint creates the AWS interface endpoint, then adds it to the Atlas endpoint service, then refreshes
+// the status of its connection. Azure and GCP endpoints already exist, they are only added and polled.
+func advanceEndpoint(req handler.Request, mongodbClient *mongodbatlas.Client, currentModel *Model, state *createState, i int) error {
+	endpoint := &state.PrivateEndpoints[i]
+	provider := currentModel.cloudProvider()
+
+	if endpoint.InterfaceEndpointID == "" && provider != privateendpoint.ProviderAWS {
+		endpoint.InterfaceEndpointID = currentModel.endpointID(i)
+	}
+	if endpoint.InterfaceEndpointID == "" {
+		id, err := awsvpcendpoint.Create(req, state.EndpointServiceName, *currentModel.Region, currentModel.newAwsPrivateEndpointInput(i),
+			clientToken(state.EndpointServiceID, i))
+		if err != nil {
+			return err
+		}
+		endpoint.InterfaceEndpointID = id
+	}
+
+	if !endpoint.IsAdded() {
+		connection := currentModel.newInterfaceEndpointConnection(i, endpoint.InterfaceEndpointID)
+		if _, err := privateendpoint.Add(mongodbClient, provider, *currentModel.GroupId, state.EndpointServiceID, connection); err != nil {
+			return err
+		}
+		endpoint.Status = privateendpoint.StatusInitiating
+		return nil
+	}
+
+	if endpoint.Status == privateendpoint.StatusAvailable {
+		return nil
+	}
+	status, _, err := privateendpoint.GetStatus(mongodbClient, provider, *currentModel.GroupId, state.EndpointServiceID, endpoint.InterfaceEndpointID)
+	if err != nil {
+		return err
+	}
+	if status != privateendpoint.StatusAvailable && !privateendpoint.IsPending(status) {
+		return fmt.Errorf("%w: endpoint %s is in status %s", errEndpointFailed, endpoint.InterfaceEndpointID, status)
+	}
+	endpoint.Status = status
+	return nil
+}
+
+// releaseEndpoints removes the interface endpoints created so far once the creation failed, so they aren't orphaned.
+// Azure and GCP endpoints are only removed from the endpoint service, they weren't created by the resource.
+func releaseEndpoints(req handler.Request, mongodbClient *mongodbatlas.Client, currentModel *Model, state *createState) error {
+	provider := currentModel.cloudProvider()
+	var errs []error
+	for i := range state.PrivateEndpoints {
+		endpoint := &state.PrivateEndpoints[i]
+		if endpoint.InterfaceEndpointID == "" {
+			continue
+		}
+		if endpoint.IsAdded() {
+			if pe := privateendpoint.Delete(mongodbClient, provider, *currentModel.GroupId, state.EndpointServiceID, []string{endpoint.InterfaceEndpointID}); pe != nil {
+				errs = append(errs, fmt.Errorf("private endpoint %s: %s", endpoint.InterfaceEndpointID, pe.Message))
+			}
+		}
+		if provider != privateendpoint.ProviderAWS {
+			continue
+		}
+		if pe := awsvpcendpoint.Delete(req, []string{endpoint.InterfaceEndpointID}, *currentModel.Region,
+			util.SafeString(currentModel.PrivateEndpoints[i].RoleArn)); pe != nil {
+			errs = append(errs, fmt.Errorf("interface endpoint %s: %s", endpoint.InterfaceEndpointID, pe.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// endpointsReleased reports whether Atlas removed the endpoints from the endpoint service, which can only be deleted
+// once it has none
+func endpointsReleased(mongodbClient *mongodbatlas.Client, currentModel *Model, state *createState) (bool, error) {
+	if state.EndpointServiceID == "" {
+		return true, nil
+	}
+	provider := currentModel.cloudProvider()
+	service, response, err := mongodbClient.PrivateEndpoints.Get(context.Background(), *currentModel.GroupId, provider, state.EndpointServiceID)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+	return len(endpointIDs(*service, provider)) == 0, nil
+}
+
+// deleteAwsEndpoints deletes the interface endpoints, each one in the account of the private endpoint that created it
+func deleteAwsEndpoints(req handler.Request, currentModel *Model, interfaceEndpoints []string) *handler.ProgressEvent {
+	roleByEndpoint := map[string]string{}
+	for i := range currentModel.PrivateEndpoints {
+		if id := currentModel.PrivateEndpoints[i].InterfaceEndpointId; id != nil {
+			roleByEndpoint[*id] = util.SafeString(currentModel.PrivateEndpoints[i].RoleArn)
+		}
+	}
+
+	endpointsByRole := map[string][]string{}
+	for _, id := range interfaceEndpoints {
+		role := roleByEndpoint[id]
+		endpointsByRole[role] = append(endpointsByRole[role], id)
+	}
+	for role, ids := range endpointsByRole {
+		if pe := awsvpcendpoint.Delete(req, ids, *currentModel.Region, role); pe != nil {
+			return pe
+		}
+	}
+	return nil
+}
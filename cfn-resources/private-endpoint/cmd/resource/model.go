@@ -5,6 +5,7 @@ package resource
 // Model is autogenerated from the json schema
 type Model struct {
 	Profile             *string           `json:",omitempty"`
+	CloudProvider       *string           `json:",omitempty"`
 	Id                  *string           `json:",omitempty"`
 	EndpointServiceName *string           `json:",omitempty"`
 	ErrorMessage        *string           `json:",omitempty"`
@@ -17,9 +18,23 @@ type Model struct {
 
 // PrivateEndpoint is autogenerated from the json schema
 type PrivateEndpoint struct {
-	VpcId                      *string  `json:",omitempty"`
-	SubnetIds                  []string `json:",omitempty"`
-	InterfaceEndpointId        *string  `json:",omitempty"`
-	AWSPrivateEndpointStatus   *string  `json:",omitempty"`
-	AtlasPrivateEndpointStatus *string  `json:",omitempty"`
+	VpcId                          *string             `json:",omitempty"`
+	SubnetIds                      []string            `json:",omitempty"`
+	SecurityGroupIds               []string            `json:",omitempty"`
+	PrivateDnsEnabled              *bool               `json:",omitempty"`
+	RoleArn                        *string             `json:",omitempty"`
+	AzurePrivateEndpointResourceId *string             `json:",omitempty"`
+	AzurePrivateEndpointIpAddress  *string             `json:",omitempty"`
+	GcpProjectId                   *string             `json:",omitempty"`
+	GcpEndpointGroupName           *string             `json:",omitempty"`
+	GcpForwardingRules             []GcpForwardingRule `json:",omitempty"`
+	InterfaceEndpointId            *string             `json:",omitempty"`
+	AWSPrivateEndpointStatus       *string             `json:",omitempty"`
+	AtlasPrivateEndpointStatus     *string             `json:",omitempty"`
+}
+
+// GcpForwardingRule is autogenerated from the json schema
+type GcpForwardingRule struct {
+	EndpointName *string `json:",omitempty"`
+	IpAddress    *string `json:",omitempty"`
 }
@@ -23,10 +23,7 @@ import (
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	resource_constats "github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/constants"
-	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/awsvpcendpoint"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/privateendpoint"
-	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/resource/steps/privateendpointservice"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/profile"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
@@ -36,10 +33,6 @@ import (
 	"go.mongodb.org/atlas/mongodbatlas"
 )
 
-const (
-	providerName = "AWS"
-)
-
 func setup() {
 	util.SetupLogger("mongodb-atlas-private-endpoint")
 }
@@ -50,26 +43,6 @@ var UpdateRequiredFields []string
 var DeleteRequiredFields = []string{constants.GroupID, constants.ID}
 var ListRequiredFields = []string{constants.GroupID}
 
-func (m *Model) newAwsPrivateEndpointInput() []awsvpcendpoint.AwsPrivateEndpointInput {
-	awsInput := make([]awsvpcendpoint.AwsPrivateEndpointInput, len(m.PrivateEndpoints))
-
-	for i, ep := range m.PrivateEndpoints {
-		subnetIds := make([]string, len(ep.SubnetIds))
-
-		copy(subnetIds, m.PrivateEndpoints[i].SubnetIds)
-
-		endpoint := awsvpcendpoint.AwsPrivateEndpointInput{
-			VpcID:               *ep.VpcId,
-			SubnetIDs:           subnetIds,
-			InterfaceEndpointID: ep.InterfaceEndpointId,
-		}
-
-		awsInput[i] = endpoint
-	}
-
-	return awsInput
-}
-
 // Create handles the Create event from the Cloudformation service.
 func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
@@ -78,6 +51,9 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		_, _ = logger.Warnf("Validation Error")
 		return *errEvent, nil
 	}
+	if errEvent := validatePrivateEndpoints(currentModel); errEvent != nil {
+		return *errEvent, nil
+	}
 
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
@@ -88,70 +64,14 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *pe, nil
 	}
 
-	status, pe := getProcessStatus(req)
-	if pe != nil {
+	if pe = newCreateWorkflow(req, mongodbClient, currentModel).Run(req.CallbackContext, currentModel); pe != nil {
 		return *pe, nil
 	}
 
-	switch status {
-	case resource_constats.Init:
-		pe := privateendpointservice.Create(*mongodbClient, *currentModel.Region, *currentModel.GroupId)
-		return addModelToProgressEvent(&pe, currentModel), nil
-	case resource_constats.CreatingPrivateEndpointService:
-		peConnection, completionValidation := privateendpointservice.ValidateCreationCompletion(mongodbClient,
-			*currentModel.GroupId, req)
-		if completionValidation != nil {
-			return addModelToProgressEvent(completionValidation, currentModel), nil
-		}
-
-		awsPrivateEndpointOutput, progressEvent := awsvpcendpoint.Create(req, peConnection.EndpointServiceName, *currentModel.Region,
-			currentModel.newAwsPrivateEndpointInput())
-		if progressEvent != nil {
-			return addModelToProgressEvent(progressEvent, currentModel), nil
-		}
-
-		privateEndpointInput := make([]privateendpoint.AtlasPrivateEndpointInput, len(awsPrivateEndpointOutput))
-
-		for i, awsPe := range awsPrivateEndpointOutput {
-			privateEndpointInput[i] = privateendpoint.AtlasPrivateEndpointInput{
-				VpcID:               awsPe.VpcID,
-				SubnetIDs:           awsPe.SubnetIDs,
-				InterfaceEndpointID: awsPe.InterfaceEndpointID,
-			}
-		}
-
-		pe := privateendpoint.Create(mongodbClient, *currentModel.GroupId, privateEndpointInput, peConnection.ID)
-
-		return addModelToProgressEvent(&pe, currentModel), nil
-	default:
-		ValidationOutput, progressEvent := privateendpoint.ValidateCreationCompletion(mongodbClient, *currentModel.GroupId, req)
-		if progressEvent != nil {
-			return addModelToProgressEvent(progressEvent, currentModel), nil
-		}
-
-		currentModel.Id = &ValidationOutput.ID
-
-		for _, cmpe := range currentModel.PrivateEndpoints {
-			for i := range ValidationOutput.Endpoints {
-				vpe := ValidationOutput.Endpoints[i]
-				if vpe.VpcID == *cmpe.VpcId && CompareSlices(vpe.SubnetIDs, cmpe.SubnetIds) {
-					currentModel.PrivateEndpoints[i].InterfaceEndpointId = &vpe.InterfaceEndpointID
-				}
-			}
-		}
-
-		privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.Get(context.Background(), *currentModel.GroupId, providerName, *currentModel.Id)
-		if err != nil {
-			return progress_events.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
-				response.Response), nil
-		}
-		currentModel.EndpointServiceName = &privateEndpointResponse.EndpointServiceName
-
-		return handler.ProgressEvent{
-			OperationStatus: handler.Success,
-			Message:         "Create Completed",
-			ResourceModel:   currentModel}, nil
-	}
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         "Create Completed",
+		ResourceModel:   currentModel}, nil
 }
 
 // Read handles the Read event from the Cloudformation service.
@@ -172,7 +92,8 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		return *pe, nil
 	}
 
-	privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.Get(context.Background(), *currentModel.GroupId, providerName, *currentModel.Id)
+	privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.Get(context.Background(), *currentModel.GroupId, currentModel.cloudProvider(),
+		*currentModel.Id)
 	if err != nil {
 		return progress_events.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
 			response.Response), nil
@@ -208,7 +129,7 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *pe, nil
 	}
 	privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.Get(context.Background(),
-		*currentModel.GroupId, providerName, *currentModel.Id)
+		*currentModel.GroupId, currentModel.cloudProvider(), *currentModel.Id)
 
 	if isDeleting(req) {
 		if response.StatusCode == http.StatusNotFound {
@@ -232,23 +153,24 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			cloudformation.HandlerErrorCodeNotFound), nil
 	}
 
-	privateEndpoint := *privateEndpointResponse
-
-	if hasInterfaceEndpoints(privateEndpoint) {
-		epr := privateendpoint.Delete(mongodbClient, *currentModel.GroupId, *currentModel.Id,
-			privateEndpoint.InterfaceEndpoints)
+	provider := currentModel.cloudProvider()
+	if endpoints := endpointIDs(*privateEndpointResponse, provider); len(endpoints) != 0 {
+		epr := privateendpoint.Delete(mongodbClient, provider, *currentModel.GroupId, *currentModel.Id,
+			endpoints)
 
 		if epr != nil {
 			return *epr, nil
 		}
 
-		epr = awsvpcendpoint.Delete(req, privateEndpoint.InterfaceEndpoints, *currentModel.Region)
-		if epr != nil {
-			return *epr, nil
+		if provider == privateendpoint.ProviderAWS {
+			epr = deleteAwsEndpoints(req, currentModel, endpoints)
+			if epr != nil {
+				return *epr, nil
+			}
 		}
 	} else {
 		response, err = mongodbClient.PrivateEndpoints.Delete(context.Background(), *currentModel.GroupId,
-			providerName,
+			provider,
 			*currentModel.Id)
 
 		if err != nil {
@@ -292,7 +214,7 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 
 	privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.List(context.Background(),
 		*currentModel.GroupId,
-		providerName,
+		currentModel.cloudProvider(),
 		params)
 	if err != nil {
 		return progress_events.GetFailedEventByResponse(fmt.Sprintf("Error listing resource : %s", err.Error()),
@@ -302,6 +224,7 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	mm := make([]interface{}, 0, len(privateEndpointResponse))
 	for i := range privateEndpointResponse {
 		var m Model
+		m.CloudProvider = currentModel.CloudProvider
 		m.completeByConnection(privateEndpointResponse[i])
 		m.Region = currentModel.Region
 		m.Profile = currentModel.Profile
@@ -325,8 +248,17 @@ func isDeleting(req handler.Request) bool {
 	return callbackValue == "DELETING"
 }
 
-func hasInterfaceEndpoints(p mongodbatlas.PrivateEndpointConnection) bool {
-	return len(p.InterfaceEndpoints) != 0
+// endpointIDs returns the IDs of the endpoints of the endpoint service, which Atlas lists in a different field for
+// each cloud provider
+func endpointIDs(p mongodbatlas.PrivateEndpointConnection, provider string) []string {
+	switch provider {
+	case privateendpoint.ProviderAzure:
+		return p.PrivateEndpoints
+	case privateendpoint.ProviderGCP:
+		return p.EndpointGroupNames
+	default:
+		return p.InterfaceEndpoints
+	}
 }
 
 func (m *Model) completeByConnection(c mongodbatlas.PrivateEndpointConnection) {
@@ -335,58 +267,5 @@ func (m *Model) completeByConnection(c mongodbatlas.PrivateEndpointConnection) {
 	m.ErrorMessage = &c.ErrorMessage
 	m.Status = &c.Status
 
-	copy(m.InterfaceEndpoints, c.InterfaceEndpoints)
-}
-
-func getProcessStatus(req handler.Request) (resource_constats.EventStatus, *handler.ProgressEvent) {
-	callback := req.CallbackContext["StateName"]
-	if callback == nil {
-		return resource_constats.Init, nil
-	}
-
-	eventStatus, err := resource_constats.ParseEventStatus(fmt.Sprintf("%v", callback))
-
-	if err != nil {
-		pe := progress_events.GetFailedEventByCode(fmt.Sprintf("Error parsing callback status : %s", err.Error()),
-			cloudformation.HandlerErrorCodeServiceInternalError)
-		return "", &pe
-	}
-
-	return eventStatus, nil
-}
-
-func addModelToProgressEvent(progressEvent *handler.ProgressEvent, model *Model) handler.ProgressEvent {
-	if progressEvent.OperationStatus == handler.InProgress {
-		progressEvent.ResourceModel = model
-
-		callbackID := progressEvent.CallbackContext["ID"]
-
-		if callbackID != nil {
-			id := fmt.Sprint(callbackID)
-			model.Id = &id
-		}
-	}
-
-	return *progressEvent
-}
-
-func CompareSlices(a []string, b []string) bool {
-	if len(a) != len(b) {
-		return false
-	}
-
-	for _, v := range a {
-		found := false
-		for _, c := range b {
-			if v == c {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-
-	return true
+	copy(m.InterfaceEndpoints, endpointIDs(c, m.cloudProvider()))
 }
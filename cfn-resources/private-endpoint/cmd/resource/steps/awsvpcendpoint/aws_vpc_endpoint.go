@@ -20,71 +20,60 @@ import (
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	progress_events "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
 )
 
-func newEc2Client(region string, req handler.Request) *ec2.EC2 {
-	return ec2.New(req.Session, aws.NewConfig().WithRegion(region))
+// newEc2Client returns an EC2 client for the account of the stack, or for the account of the role when one is given
+func newEc2Client(region string, req handler.Request, roleArn string) *ec2.EC2 {
+	config := aws.NewConfig().WithRegion(region)
+	if roleArn != "" {
+		config = config.WithCredentials(stscreds.NewCredentials(req.Session, roleArn))
+	}
+	return ec2.New(req.Session, config)
 }
 
 type AwsPrivateEndpointInput struct {
-	VpcID               string
-	SubnetIDs           []string
-	InterfaceEndpointID *string
-}
-
-type AwsPrivateEndpointOutput struct {
-	VpcID               string
-	SubnetIDs           []string
-	InterfaceEndpointID string
+	VpcID             string
+	SubnetIDs         []string
+	SecurityGroupIDs  []string
+	PrivateDNSEnabled bool
+	RoleArn           string
 }
 
 func convertToAWSRegion(region string) string {
 	return strings.ReplaceAll(strings.ToLower(region), "_", "-")
 }
 
-func Create(req handler.Request, endpointServiceName string, region string, privateEndpointInputs []AwsPrivateEndpointInput) ([]AwsPrivateEndpointOutput, *handler.ProgressEvent) {
-	svc := newEc2Client(convertToAWSRegion(region), req)
-
-	vcpType := "Interface"
-
-	subnetIds := make([]AwsPrivateEndpointOutput, len(privateEndpointInputs))
-
-	for i, pe := range privateEndpointInputs {
-		subnetIdsIn := make([]*string, len(pe.SubnetIDs))
-
-		for i := range pe.SubnetIDs {
-			subnetIdsIn[i] = &(pe.SubnetIDs[i])
-		}
-
-		connection := ec2.CreateVpcEndpointInput{
-			VpcId:           &pe.VpcID,
-			ServiceName:     &endpointServiceName,
-			VpcEndpointType: &vcpType,
-			SubnetIds:       subnetIdsIn,
-		}
-
-		vpcE, err := svc.CreateVpcEndpoint(&connection)
-		if err != nil {
-			fpe := progress_events.GetFailedEventByCode(fmt.Sprintf("Error creating vcp Endpoint: %s", err.Error()),
-				cloudformation.HandlerErrorCodeGeneralServiceException)
-			return nil, &fpe
-		}
-
-		subnetIds[i] = AwsPrivateEndpointOutput{
-			VpcID:               pe.VpcID,
-			SubnetIDs:           pe.SubnetIDs,
-			InterfaceEndpointID: *vpcE.VpcEndpoint.VpcEndpointId,
-		}
+// Create creates the interface endpoint of one VPC and returns its ID. The client token makes a retry return the
+// endpoint of a previous attempt instead of creating another one.
+func Create(req handler.Request, endpointServiceName string, region string, input AwsPrivateEndpointInput, clientToken string) (string, error) {
+	svc := newEc2Client(convertToAWSRegion(region), req, input.RoleArn)
+
+	connection := ec2.CreateVpcEndpointInput{
+		ClientToken:       aws.String(clientToken),
+		VpcId:             aws.String(input.VpcID),
+		ServiceName:       aws.String(endpointServiceName),
+		VpcEndpointType:   aws.String(ec2.VpcEndpointTypeInterface),
+		SubnetIds:         aws.StringSlice(input.SubnetIDs),
+		PrivateDnsEnabled: aws.Bool(input.PrivateDNSEnabled),
+	}
+	if len(input.SecurityGroupIDs) > 0 {
+		connection.SecurityGroupIds = aws.StringSlice(input.SecurityGroupIDs)
 	}
 
-	return subnetIds, nil
+	vpcE, err := svc.CreateVpcEndpoint(&connection)
+	if err != nil {
+		return "", fmt.Errorf("error creating vpc endpoint in %s: %w", input.VpcID, err)
+	}
+	return aws.StringValue(vpcE.VpcEndpoint.VpcEndpointId), nil
 }
 
-func Delete(req handler.Request, interfaceEndpoints []string, region string) *handler.ProgressEvent {
-	svc := newEc2Client(convertToAWSRegion(region), req)
+// Delete deletes interface endpoints of the account of the stack, or of the account of the role when one is given
+func Delete(req handler.Request, interfaceEndpoints []string, region string, roleArn string) *handler.ProgressEvent {
+	svc := newEc2Client(convertToAWSRegion(region), req, roleArn)
 
 	vpcEndpointIds := make([]*string, 0)
 
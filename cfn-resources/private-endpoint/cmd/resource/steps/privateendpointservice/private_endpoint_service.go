@@ -16,123 +16,73 @@ package privateendpointservice
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/mongodb/mongodbatlas-cloudformation-resources/private-endpoint/cmd/constants"
 	progress_events "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
 	"go.mongodb.org/atlas/mongodbatlas"
 )
 
 const (
-	ProviderName     = "AWS"
 	AvailableStatus  = "AVAILABLE"
 	InitiatingStatus = "INITIATING"
 )
 
-type privateEndpointCreationCallBackContext struct {
-	StateName constants.EventStatus
-	ID        string
-}
-
-func (s *privateEndpointCreationCallBackContext) FillStruct(m map[string]interface{}) error {
-	s.ID = fmt.Sprint(m["ID"])
-	eventStatusParam := fmt.Sprint(m["StateName"])
-	eventStatus, err := constants.ParseEventStatus(eventStatusParam)
-	if err != nil {
-		return err
-	}
-
-	s.StateName = eventStatus
-
-	return nil
-}
-
-func Create(mongodbClient mongodbatlas.Client, region string, groupID string) handler.ProgressEvent {
+// Create creates the endpoint service of the cloud provider in the region and returns its ID
+func Create(mongodbClient *mongodbatlas.Client, providerName, region, groupID string) (string, error) {
 	privateEndpointRequest := &mongodbatlas.PrivateEndpointConnection{
-		ProviderName: ProviderName,
+		ProviderName: providerName,
 		Region:       region,
 	}
 
 	privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.Create(context.Background(),
 		groupID,
 		privateEndpointRequest)
-
-	if response.Response.StatusCode == http.StatusConflict {
-		return handler.ProgressEvent{
-			OperationStatus:  handler.Failed,
-			Message:          "Resource already exists",
-			HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}
-	}
-
 	if err != nil {
-		return progress_events.GetFailedEventByResponse(fmt.Sprintf("Error creating resource : %s", err.Error()),
-			response.Response)
+		return "", responseError("Error creating resource", response, err)
 	}
 
-	callBackContext := privateEndpointCreationCallBackContext{
-		StateName: constants.CreatingPrivateEndpointService,
-		ID:        privateEndpointResponse.ID,
-	}
-
-	var callBackMap map[string]interface{}
-	data, _ := json.Marshal(callBackContext)
-	err = json.Unmarshal(data, &callBackMap)
-	if err != nil {
-		return progress_events.GetFailedEventByResponse(fmt.Sprintf("Error Unmarshalling callback map : %s", err.Error()),
-			response.Response)
-	}
-
-	return progress_events.GetInProgressProgressEvent("Creating private endpoint service", callBackMap,
-		nil, 20)
+	return privateEndpointResponse.ID, nil
 }
 
-func ValidateCreationCompletion(mongodbClient *mongodbatlas.Client, groupID string, req handler.Request) (*mongodbatlas.PrivateEndpointConnection, *handler.ProgressEvent) {
-	PrivateEndpointCallBackContext := privateEndpointCreationCallBackContext{}
-
-	err := PrivateEndpointCallBackContext.FillStruct(req.CallbackContext)
-	if err != nil {
-		ev := progress_events.GetFailedEventByCode(fmt.Sprintf("Error parsing PrivateEndpointCallBackContext : %s", err.Error()),
-			cloudformation.HandlerErrorCodeServiceInternalError)
-		return nil, &ev
-	}
-
+// GetAvailable returns the endpoint service once it's available, and nil while Atlas initiates it
+func GetAvailable(mongodbClient *mongodbatlas.Client, providerName, groupID, endpointServiceID string) (*mongodbatlas.PrivateEndpointConnection, error) {
 	privateEndpointResponse, response, err := mongodbClient.PrivateEndpoints.Get(context.Background(), groupID,
-		ProviderName, PrivateEndpointCallBackContext.ID)
+		providerName, endpointServiceID)
 	if err != nil {
-		ev := progress_events.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
-			response.Response)
-		return nil, &ev
+		return nil, responseError("Error getting resource", response, err)
 	}
 
 	switch privateEndpointResponse.Status {
 	case InitiatingStatus:
-		callBackContext := privateEndpointCreationCallBackContext{
-			StateName: constants.CreatingPrivateEndpointService,
-			ID:        privateEndpointResponse.ID,
-		}
-
-		var callBackMap map[string]interface{}
-		data, _ := json.Marshal(callBackContext)
-		err = json.Unmarshal(data, &callBackMap)
-		if err != nil {
-			ev := progress_events.GetFailedEventByResponse(fmt.Sprintf("Error Unmarshalling callback map : %s", err.Error()),
-				response.Response)
-			return nil, &ev
-		}
-
-		ev := progress_events.GetInProgressProgressEvent("Private endpoint service initiating", callBackMap,
-			nil, 20)
-		return nil, &ev
+		return nil, nil
 	case AvailableStatus:
 		return privateEndpointResponse, nil
 	default:
-		ev := progress_events.GetFailedEventByCode(fmt.Sprintf("Error creating private endpoint in status : %s",
-			privateEndpointResponse.Status),
-			cloudformation.HandlerErrorCodeInvalidRequest)
-		return nil, &ev
+		return nil, workflow.Fail(cloudformation.HandlerErrorCodeInvalidRequest,
+			fmt.Errorf("error creating private endpoint in status : %s", privateEndpointResponse.Status))
+	}
+}
+
+// Delete deletes the endpoint service, an endpoint service that is already gone isn't an error
+func Delete(mongodbClient *mongodbatlas.Client, providerName, groupID, endpointServiceID string) error {
+	response, err := mongodbClient.PrivateEndpoints.Delete(context.Background(), groupID, providerName, endpointServiceID)
+	if err != nil && (response == nil || response.StatusCode != http.StatusNotFound) {
+		return responseError("Error deleting resource", response, err)
+	}
+	return nil
+}
+
+func responseError(message string, response *mongodbatlas.Response, err error) error {
+	if response == nil {
+		return err
+	}
+	if response.StatusCode == http.StatusConflict {
+		return workflow.Fail(cloudformation.HandlerErrorCodeAlreadyExists, errors.New("resource already exists"))
 	}
+	pe := progress_events.GetFailedEventByResponse(fmt.Sprintf("%s : %s", message, err.Error()), response.Response)
+	return workflow.EventError(&pe)
 }
@@ -4,9 +4,19 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	ProjectId  *string `json:",omitempty"`
-	Profile    *string `json:",omitempty"`
-	EndpointId *string `json:",omitempty"`
-	Type       *string `json:",omitempty"`
-	Comment    *string `json:",omitempty"`
+	ProjectId                                 *string                   `json:",omitempty"`
+	Profile                                   *string                   `json:",omitempty"`
+	EndpointId                                *string                   `json:",omitempty"`
+	Type                                      *string                   `json:",omitempty"`
+	Comment                                   *string                   `json:",omitempty"`
+	CreateAndAssignAWSPrivateEndpoint         *bool                     `json:",omitempty"`
+	AwsPrivateEndpointConfigurationProperties *AwsPrivateEndpointConfig `json:",omitempty"`
+}
+
+// AwsPrivateEndpointConfig is autogenerated from the json schema
+type AwsPrivateEndpointConfig struct {
+	VpcId               *string  `json:",omitempty"`
+	SubnetIds           []string `json:",omitempty"`
+	Region              *string  `json:",omitempty"`
+	EndpointServiceName *string  `json:",omitempty"`
 }
@@ -31,6 +31,7 @@ import (
 )
 
 var CreateRequiredFields = []string{constants.ProjectID, constants.EndpointID}
+var CreateWithAwsPrivateEndpointRequiredFields = []string{constants.ProjectID}
 var ReadRequiredFields = []string{constants.ProjectID, constants.EndpointID}
 var DeleteRequiredFields = []string{constants.ProjectID, constants.EndpointID}
 var ListRequiredFields = []string{constants.ProjectID}
@@ -47,7 +48,7 @@ func setup() {
 func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
 	setup()
 
-	modelValidation := validator.ValidateModel(CreateRequiredFields, currentModel)
+	modelValidation := currentModel.validateAwsPrivateEndpointProperties()
 	if modelValidation != nil {
 		return *modelValidation, nil
 	}
@@ -62,25 +63,31 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	readModel := Model{ProjectId: currentModel.ProjectId, EndpointId: currentModel.EndpointId}
-	readResponse, err := readModel.getPrivateEndpoint(atlas)
-	defer closeResponse(readResponse)
-	if err == nil {
-		return handler.ProgressEvent{
-			OperationStatus:  handler.Failed,
-			Message:          AlreadyExists,
-			HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}, nil
-	}
+	if currentModel.createsAwsPrivateEndpoint() {
+		if pe := newCreateWorkflow(req, atlas, currentModel).Run(req.CallbackContext, currentModel); pe != nil {
+			return *pe, nil
+		}
+	} else {
+		readModel := Model{ProjectId: currentModel.ProjectId, EndpointId: currentModel.EndpointId}
+		readResponse, err := readModel.getPrivateEndpoint(atlas)
+		defer closeResponse(readResponse)
+		if err == nil {
+			return handler.ProgressEvent{
+				OperationStatus:  handler.Failed,
+				Message:          AlreadyExists,
+				HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}, nil
+		}
 
-	response, err := createOrUpdate(currentModel, atlas)
+		response, err := createOrUpdate(currentModel, atlas)
 
-	defer closeResponse(response)
-	if err != nil {
-		return handleError(response, err)
+		defer closeResponse(response)
+		if err != nil {
+			return handleError(response, err)
+		}
 	}
 
 	// Read endpoint
-	readResponse, err = currentModel.getPrivateEndpoint(atlas)
+	readResponse, err := currentModel.getPrivateEndpoint(atlas)
 	defer closeResponse(readResponse)
 	if err != nil {
 		return handleError(readResponse, err)
@@ -213,6 +220,9 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	if err != nil {
 		return handleError(response, err)
 	}
+	if pe := deleteAwsPrivateEndpoint(req, currentModel); pe != nil {
+		return *pe, nil
+	}
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Delete Completed",
@@ -36,6 +36,10 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return progressevents.GetFailedEventByCode("AccessList must not be empty", cloudformation.HandlerErrorCodeInvalidRequest), nil
 	}
 
+	if errEvent := validateEntries(currentModel.AccessList, nil); errEvent != nil {
+		return *errEvent, nil
+	}
+
 	util.SetDefaultProfileIfNotDefined(&currentModel.Profile)
 	// Create atlas client
 	client, peErr := util.NewAtlasClient(&req, currentModel.Profile)
@@ -57,16 +61,6 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 }
 
 func createEntries(model *Model, client *util.MongoDBClient) (handler.ProgressEvent, error) {
-	request, err := newPaginatedNetworkAccess(model)
-	if err != nil {
-		return handler.ProgressEvent{
-			Message:          "Error in parsing the resource schema",
-			OperationStatus:  handler.Failed,
-			HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}, err
-	}
-
-	projectID := *model.ProjectId
-
 	if isEntryAlreadyInAccessList, err := isEntryAlreadyInAccessList(client, model); isEntryAlreadyInAccessList || err != nil {
 		if err != nil {
 			return handler.ProgressEvent{
@@ -80,7 +74,21 @@ func createEntries(model *Model, client *util.MongoDBClient) (handler.ProgressEv
 			HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}, err
 	}
 
-	if _, _, err := client.AtlasV2.ProjectIPAccessListApi.CreateProjectIpAccessList(context.Background(), projectID, &request.Results).Execute(); err != nil {
+	return addEntries(model.AccessList, *model.ProjectId, client)
+}
+
+// addEntries adds the entries to the access list. Atlas updates the comment and the expiry date of the entries
+// that are already in it.
+func addEntries(entries []AccessListDefinition, projectID string, client *util.MongoDBClient) (handler.ProgressEvent, error) {
+	request, err := newPaginatedNetworkAccess(entries)
+	if err != nil {
+		return handler.ProgressEvent{
+			Message:          "Error in parsing the resource schema",
+			OperationStatus:  handler.Failed,
+			HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}, err
+	}
+
+	if _, _, err = client.AtlasV2.ProjectIPAccessListApi.CreateProjectIpAccessList(context.Background(), projectID, &request.Results).Execute(); err != nil {
 		_, _ = logger.Warnf("Error createEntries projectId:%s, err:%+v", projectID, err)
 		return handler.ProgressEvent{
 			Message:          err.Error(),
@@ -15,7 +15,6 @@
 package resource
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
@@ -43,20 +42,38 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		return *peErr, nil
 	}
 
-	result, resp, err := client.AtlasV2.ProjectIPAccessListApi.ListProjectIpAccessLists(context.Background(), *currentModel.ProjectId).Execute()
+	entries, resp, err := getAllEntries(client, *currentModel.ProjectId)
 	if err != nil {
 		return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error getting resource : %s", err.Error()),
 			resp), nil
 	}
 
-	if *result.TotalCount == 0 {
+	// the entries that Atlas removed, like the expired ones, are no longer part of the resource
+	if len(currentModel.AccessList) > 0 {
+		existingEntries := newAccessListMap(entries)
+		accessList := make([]AccessListDefinition, 0, len(currentModel.AccessList))
+		for _, entry := range currentModel.AccessList {
+			if isEntryInMap(entry, existingEntries) {
+				accessList = append(accessList, entry)
+			}
+		}
+		currentModel.AccessList = accessList
+	} else {
+		for i := range entries {
+			var m AccessListDefinition
+			m.completeByConnection(entries[i])
+			currentModel.AccessList = append(currentModel.AccessList, m)
+		}
+	}
+
+	if len(currentModel.AccessList) == 0 {
 		return handler.ProgressEvent{
 			Message:          "The entry to read is not in the access list",
 			OperationStatus:  handler.Failed,
 			HandlerErrorCode: cloudformation.HandlerErrorCodeNotFound}, nil
 	}
 
-	currentModel.TotalCount = result.TotalCount
+	currentModel.TotalCount = util.Pointer(len(entries))
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
 		Message:         "Read Complete",
@@ -18,8 +18,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
@@ -38,15 +41,59 @@ var UpdateRequiredFields = []string{constants.ProjectID, constants.AccessList}
 var DeleteRequiredFields = []string{constants.ProjectID}
 var ListRequiredFields = []string{constants.ProjectID}
 
+const itemsPerPage = 500
+
 // function to validate inputs to all actions
 func validateModel(fields []string, model *Model) *handler.ProgressEvent {
 	return validator.ValidateModel(fields, model)
 }
 
-func newPaginatedNetworkAccess(model *Model) (*admin.PaginatedNetworkAccess, error) {
+// validateEntries checks that every entry is one security group, one IP address or one CIDR block, and that the
+// temporary entries expire in the future. Atlas doesn't accept temporary security groups. The entries of prev that
+// expired since are still accepted as long as their DeleteAfterDate is unchanged, the template keeps listing them.
+func validateEntries(entries, prev []AccessListDefinition) *handler.ProgressEvent {
+	prevDates := expiryDates(prev)
+	for i := range entries {
+		entry := &entries[i]
+		set := 0
+		for _, value := range []*string{entry.CIDRBlock, entry.IPAddress, entry.AwsSecurityGroup} {
+			if util.IsStringPresent(value) {
+				set++
+			}
+		}
+		if set != 1 {
+			pe := progressevents.GetFailedEventByCode(fmt.Sprintf("AccessList entry %d must have exactly one of CIDRBlock, IPAddress or AwsSecurityGroup", i),
+				cloudformation.HandlerErrorCodeInvalidRequest)
+			return &pe
+		}
+
+		if !util.IsStringPresent(entry.DeleteAfterDate) || entry.isExpiredSince(prevDates) {
+			continue
+		}
+		if util.IsStringPresent(entry.AwsSecurityGroup) {
+			pe := progressevents.GetFailedEventByCode(fmt.Sprintf("AccessList entry %s can't have a DeleteAfterDate, security groups can't be temporary", *entry.AwsSecurityGroup),
+				cloudformation.HandlerErrorCodeInvalidRequest)
+			return &pe
+		}
+		deleteAfterDate, err := util.StringToTime(*entry.DeleteAfterDate)
+		if err != nil {
+			pe := progressevents.GetFailedEventByCode(fmt.Sprintf("AccessList entry %s: DeleteAfterDate must be an ISO 8601 timestamp: %s", entry.key(), err.Error()),
+				cloudformation.HandlerErrorCodeInvalidRequest)
+			return &pe
+		}
+		if !deleteAfterDate.After(time.Now()) {
+			pe := progressevents.GetFailedEventByCode(fmt.Sprintf("AccessList entry %s: DeleteAfterDate %s is in the past", entry.key(), *entry.DeleteAfterDate),
+				cloudformation.HandlerErrorCodeInvalidRequest)
+			return &pe
+		}
+	}
+	return nil
+}
+
+func newPaginatedNetworkAccess(entries []AccessListDefinition) (*admin.PaginatedNetworkAccess, error) {
 	var accesslist []admin.NetworkPermissionEntry
-	for i := range model.AccessList {
-		modelAccessList := model.AccessList[i]
+	for i := range entries {
+		modelAccessList := entries[i]
 		projectIPAccessList := admin.NetworkPermissionEntry{}
 
 		if modelAccessList.DeleteAfterDate != nil {
@@ -131,6 +178,10 @@ func deleteEntries(model *Model, client *util.MongoDBClient) handler.ProgressEve
 		}
 
 		if _, resp, err := client.AtlasV2.ProjectIPAccessListApi.DeleteProjectIpAccessList(context.Background(), *model.ProjectId, entry).Execute(); err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound && accessListEntry.isExpired() {
+				_, _ = logger.Debugf("Accesslist entry %s expired", entry)
+				continue
+			}
 			return progressevents.GetFailedEventByResponse(fmt.Sprintf("Error deleting the resource: %s", err.Error()),
 				resp)
 		}
@@ -139,31 +190,30 @@ func deleteEntries(model *Model, client *util.MongoDBClient) handler.ProgressEve
 	return handler.ProgressEvent{}
 }
 
-func getAllEntries(client *util.MongoDBClient, projectID string) (*admin.PaginatedNetworkAccess, error) {
-	includeCount := true
-	itemPerPage := 500
-
-	listOptions := &admin.ListProjectIpAccessListsApiParams{
-		GroupId:      projectID,
-		IncludeCount: &includeCount,
-		ItemsPerPage: &itemPerPage,
-	}
-	accessList, _, err := client.AtlasV2.ProjectIPAccessListApi.ListProjectIpAccessListsWithParams(context.Background(), listOptions).Execute()
-	if err != nil {
-		return nil, err
-	}
-
-	return accessList, nil
+// getAllEntries returns the entries of the access list of the project, from all the pages
+func getAllEntries(client *util.MongoDBClient, projectID string) ([]admin.NetworkPermissionEntry, *http.Response, error) {
+	return util.ListAll(itemsPerPage, func(pageNum int) ([]admin.NetworkPermissionEntry, *http.Response, error) {
+		listOptions := &admin.ListProjectIpAccessListsApiParams{
+			GroupId:      projectID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}
+		page, resp, err := client.AtlasV2.ProjectIPAccessListApi.ListProjectIpAccessListsWithParams(context.Background(), listOptions).Execute()
+		if err != nil {
+			return nil, resp, err
+		}
+		return page.Results, resp, nil
+	})
 }
 
 // isEntryAlreadyInAccessList checks if the entry already exists in the atlas access list
 func isEntryAlreadyInAccessList(client *util.MongoDBClient, model *Model) (bool, error) {
-	existingEntries, err := getAllEntries(client, *model.ProjectId)
+	existingEntries, _, err := getAllEntries(client, *model.ProjectId)
 	if err != nil {
 		return false, err
 	}
 
-	existingEntriesMap := newAccessListMap(existingEntries.Results)
+	existingEntriesMap := newAccessListMap(existingEntries)
 	for _, entry := range model.AccessList {
 		if isEntryInMap(entry, existingEntriesMap) {
 			return true, nil
@@ -173,41 +223,83 @@ func isEntryAlreadyInAccessList(client *util.MongoDBClient, model *Model) (bool,
 	return false, nil
 }
 
-func isEntryInMap(entry AccessListDefinition, accessListMap map[string]bool) bool {
-	if entry.CIDRBlock != nil && accessListMap[*entry.CIDRBlock] {
-		return true
+// hasEntries reports whether one of the entries is in the access list or was removed by Atlas once it expired
+func hasEntries(entries []AccessListDefinition, accessListMap map[string]admin.NetworkPermissionEntry) bool {
+	for i := range entries {
+		if entries[i].isExpired() || isEntryInMap(entries[i], accessListMap) {
+			return true
+		}
 	}
+	return false
+}
+
+func isEntryInMap(entry AccessListDefinition, accessListMap map[string]admin.NetworkPermissionEntry) bool {
+	_, ok := accessListMap[entry.key()]
+	return ok
+}
 
-	if entry.IPAddress != nil && accessListMap[*entry.IPAddress] {
-		return true
+// newAccessListMap indexes the entries of the access list by their key
+func newAccessListMap(accessList []admin.NetworkPermissionEntry) map[string]admin.NetworkPermissionEntry {
+	m := make(map[string]admin.NetworkPermissionEntry, len(accessList))
+	for _, entry := range accessList {
+		if key := accessListKey(entry.CidrBlock, entry.IpAddress, entry.AwsSecurityGroup); key != "" {
+			m[key] = entry
+		}
 	}
+	return m
+}
 
-	if entry.AwsSecurityGroup != nil && accessListMap[*entry.AwsSecurityGroup] {
-		return true
+// accessListKey identifies an entry by its security group, IP address or CIDR block. Atlas returns the IP address
+// of a CIDR block of a single address, so such a block has the key of its IP address.
+func accessListKey(cidrBlock, ipAddress, awsSecurityGroup *string) string {
+	switch {
+	case util.IsStringPresent(awsSecurityGroup):
+		return *awsSecurityGroup
+	case util.IsStringPresent(ipAddress):
+		return *ipAddress
+	case util.IsStringPresent(cidrBlock):
+		return strings.TrimSuffix(*cidrBlock, "/32")
+	default:
+		return ""
 	}
+}
 
-	return false
+func (m *AccessListDefinition) key() string {
+	return accessListKey(m.CIDRBlock, m.IPAddress, m.AwsSecurityGroup)
 }
 
-func newAccessListMap(accessList []admin.NetworkPermissionEntry) map[string]bool {
-	m := make(map[string]bool)
-	for _, entry := range accessList {
-		if util.IsStringPresent(entry.CidrBlock) {
-			m[*entry.CidrBlock] = true
-			continue
-		}
+// isExpired reports whether the entry is temporary and its DeleteAfterDate has passed, Atlas removes such
+// entries on its own
+func (m *AccessListDefinition) isExpired() bool {
+	deleteAfterDate := util.StringPtrToTimePtr(m.DeleteAfterDate)
+	return deleteAfterDate != nil && !deleteAfterDate.After(time.Now())
+}
 
-		if util.IsStringPresent(entry.IpAddress) {
-			m[*entry.IpAddress] = true
-			continue
-		}
+// isExpiredSince reports whether the entry expired with the expiry date it had in the previous model
+func (m *AccessListDefinition) isExpiredSince(prevDates map[string]string) bool {
+	return m.isExpired() && prevDates[m.key()] == *m.DeleteAfterDate
+}
 
-		if util.IsStringPresent(entry.AwsSecurityGroup) {
-			m[*entry.AwsSecurityGroup] = true
+// withoutExpired drops the entries that expired with the expiry date of the previous model, Atlas removed them
+// on its own
+func withoutExpired(current, prev []AccessListDefinition) []AccessListDefinition {
+	prevDates := expiryDates(prev)
+	entries := make([]AccessListDefinition, 0, len(current))
+	for i := range current {
+		if current[i].isExpiredSince(prevDates) {
 			continue
 		}
+		entries = append(entries, current[i])
 	}
-	return m
+	return entries
+}
+
+func expiryDates(entries []AccessListDefinition) map[string]string {
+	dates := make(map[string]string, len(entries))
+	for i := range entries {
+		dates[entries[i].key()] = util.SafeString(entries[i].DeleteAfterDate)
+	}
+	return dates
 }
 
 func (m *AccessListDefinition) completeByConnection(c admin.NetworkPermissionEntry) {
@@ -215,4 +307,5 @@ func (m *AccessListDefinition) completeByConnection(c admin.NetworkPermissionEnt
 	m.CIDRBlock = c.CidrBlock
 	m.Comment = c.Comment
 	m.AwsSecurityGroup = c.AwsSecurityGroup
+	m.DeleteAfterDate = util.TimePtrToStringPtr(c.DeleteAfterDate)
 }
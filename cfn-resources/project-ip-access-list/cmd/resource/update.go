@@ -15,11 +15,15 @@
 package resource
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/profile"
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
 
 func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
@@ -45,7 +49,7 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			HandlerErrorCode: cloudformation.HandlerErrorCodeNotFound}, nil
 	}
 
-	existingEntries, err := getAllEntries(client, *currentModel.ProjectId)
+	existingEntries, _, err := getAllEntries(client, *currentModel.ProjectId)
 	if err != nil {
 		return handler.ProgressEvent{
 			Message:          "Error in retrieving the existing entries",
@@ -53,7 +57,9 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			HandlerErrorCode: cloudformation.HandlerErrorCodeNotFound}, err
 	}
 
-	if *existingEntries.TotalCount == 0 {
+	// the entries that Atlas removed once they expired still belong to the resource
+	existingEntriesMap := newAccessListMap(existingEntries)
+	if !hasEntries(prevModel.AccessList, existingEntriesMap) {
 		return handler.ProgressEvent{
 			Message:          "You have no entry in the accesslist. You should use CREATE instead of UPDATE",
 			OperationStatus:  handler.Failed,
@@ -67,20 +73,44 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			HandlerErrorCode: cloudformation.HandlerErrorCodeNotFound}, nil
 	}
 
-	// We need to make sure that the entries in the previous and current model are not in the accesslist.
-	// Why do we need to do delete entries in the previous model?
-	// Scenario: If the user updates the current model by removing one of the entries in the accesslist,
-	// CFN will call the UPDATE operation which won't delete the removed entry bacause it is no longer in the current model.
-	entriesToDelete := currentModel.AccessList
-	entriesToDelete = append(entriesToDelete, prevModel.AccessList...)
+	if errEvent := validateEntries(currentModel.AccessList, prevModel.AccessList); errEvent != nil {
+		return *errEvent, nil
+	}
+	currentEntries := withoutExpired(currentModel.AccessList, prevModel.AccessList)
+
+	// Only the difference is applied, so the entries that didn't change keep granting access during the update.
+	// The new entries are added before the removed ones are deleted, so replacing an entry never leaves a gap.
+	diff := diffEntries(prevModel.AccessList, currentEntries, existingEntriesMap)
+	for _, entry := range diff.added {
+		if isEntryInMap(entry, existingEntriesMap) {
+			return handler.ProgressEvent{
+				Message:          fmt.Sprintf("Entry %s already exists in the access list", entry.key()),
+				OperationStatus:  handler.Failed,
+				HandlerErrorCode: cloudformation.HandlerErrorCodeAlreadyExists}, nil
+		}
+	}
 
-	progressEvent := deleteEntriesForUpdate(entriesToDelete, *currentModel.ProjectId, client)
-	if progressEvent.OperationStatus == handler.Failed {
-		return progressEvent, nil
+	upserted := make([]AccessListDefinition, 0, len(diff.added)+len(diff.changed))
+	upserted = append(upserted, diff.added...)
+	upserted = append(upserted, diff.changed...)
+	if len(upserted) > 0 {
+		if progressEvent, _ := addEntries(upserted, *currentModel.ProjectId, client); progressEvent.OperationStatus == handler.Failed {
+			return progressEvent, nil
+		}
 	}
 
-	progressEvent, err = createEntries(currentModel, client)
-	if progressEvent.OperationStatus == handler.Failed || err != nil {
+	// Atlas keeps the expiry date of an entry that is added again without one, so an entry that is no longer
+	// temporary is replaced
+	if len(diff.replaced) > 0 {
+		if progressEvent := deleteEntriesForUpdate(diff.replaced, *currentModel.ProjectId, client); progressEvent.OperationStatus == handler.Failed {
+			return progressEvent, nil
+		}
+		if progressEvent, _ := addEntries(diff.replaced, *currentModel.ProjectId, client); progressEvent.OperationStatus == handler.Failed {
+			return progressEvent, nil
+		}
+	}
+
+	if progressEvent := deleteEntriesForUpdate(diff.removed, *currentModel.ProjectId, client); progressEvent.OperationStatus == handler.Failed {
 		return progressEvent, nil
 	}
 
@@ -90,3 +120,59 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		ResourceModel:   currentModel,
 	}, nil
 }
+
+// accessListDiff is what an update changes in the access list
+type accessListDiff struct {
+	// added are the entries that weren't part of the resource
+	added []AccessListDefinition
+	// changed are the entries whose comment or expiry date changed
+	changed []AccessListDefinition
+	// replaced are the entries that are no longer temporary
+	replaced []AccessListDefinition
+	// removed are the entries that are no longer part of the resource
+	removed []AccessListDefinition
+}
+
+// diffEntries compares the entries of the previous and the current model, and the entries of the current model
+// with the entries of the access list. An entry of both models that is missing from the access list is added again.
+func diffEntries(prev, current []AccessListDefinition, existingEntries map[string]admin.NetworkPermissionEntry) accessListDiff {
+	var diff accessListDiff
+	prevKeys := make(map[string]bool, len(prev))
+	for i := range prev {
+		prevKeys[prev[i].key()] = true
+	}
+
+	currentKeys := make(map[string]bool, len(current))
+	for i := range current {
+		entry := current[i]
+		key := entry.key()
+		currentKeys[key] = true
+
+		existing, ok := existingEntries[key]
+		switch {
+		case !prevKeys[key]:
+			diff.added = append(diff.added, entry)
+		case !ok:
+			diff.changed = append(diff.changed, entry)
+		case existing.DeleteAfterDate != nil && !util.IsStringPresent(entry.DeleteAfterDate):
+			diff.replaced = append(diff.replaced, entry)
+		case util.SafeString(existing.Comment) != util.SafeString(entry.Comment) || !sameDate(existing.DeleteAfterDate, entry.DeleteAfterDate):
+			diff.changed = append(diff.changed, entry)
+		}
+	}
+
+	for i := range prev {
+		if !currentKeys[prev[i].key()] {
+			diff.removed = append(diff.removed, prev[i])
+		}
+	}
+	return diff
+}
+
+func sameDate(existing *time.Time, date *string) bool {
+	parsed := util.StringPtrToTimePtr(date)
+	if existing == nil || parsed == nil {
+		return existing == nil && parsed == nil
+	}
+	return existing.Equal(*parsed)
+}
@@ -0,0 +1,285 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/constants"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	dependentsCallbackSeconds = 30
+	deletingDependents        = "DELETING_DEPENDENTS"
+)
+
+var cloudProviders = []string{constants.AWS, "AZURE", "GCP"}
+
+// projectDependents are the resources of a project that keep Atlas from deleting it
+type projectDependents struct {
+	clusters            []admin.AdvancedClusterDescription
+	serverlessInstances []admin.ServerlessInstanceDescription
+	federatedDatabases  []admin.DataLakeTenant
+	privateEndpoints    []admin.EndpointService
+	peeringConnections  []admin.BaseNetworkPeeringConnectionSettings
+	containers          []admin.CloudProviderContainer
+}
+
+// blocking reports whether any dependent would make the project deletion fail. Containers don't block
+// the deletion, they are only removed by ForceDestroy.
+func (d *projectDependents) blocking() bool {
+	return len(d.clusters)+len(d.serverlessInstances)+len(d.federatedDatabases)+len(d.privateEndpoints)+len(d.peeringConnections) > 0
+}
+
+func (d *projectDependents) String() string {
+	var parts []string
+	add := func(kind string, names []string) {
+		if len(names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", kind, strings.Join(names, ", ")))
+		}
+	}
+
+	var names []string
+	for i := range d.clusters {
+		names = append(names, util.SafeString(d.clusters[i].Name))
+	}
+	add("clusters", names)
+
+	names = nil
+	for i := range d.serverlessInstances {
+		names = append(names, util.SafeString(d.serverlessInstances[i].Name))
+	}
+	add("serverless instances", names)
+
+	names = nil
+	for i := range d.federatedDatabases {
+		names = append(names, util.SafeString(d.federatedDatabases[i].Name))
+	}
+	add("federated database instances", names)
+
+	names = nil
+	for i := range d.privateEndpoints {
+		names = append(names, fmt.Sprintf("%s %s", d.privateEndpoints[i].CloudProvider, util.SafeString(d.privateEndpoints[i].Id)))
+	}
+	add("private endpoint services", names)
+
+	names = nil
+	for i := range d.peeringConnections {
+		names = append(names, fmt.Sprintf("%s %s", util.SafeString(d.peeringConnections[i].ProviderName), util.SafeString(d.peeringConnections[i].Id)))
+	}
+	add("network peering connections", names)
+
+	names = nil
+	for i := range d.containers {
+		names = append(names, fmt.Sprintf("%s %s", util.SafeString(d.containers[i].ProviderName), util.SafeString(d.containers[i].Id)))
+	}
+	add("network peering containers", names)
+
+	return strings.Join(parts, "; ")
+}
+
+// listDependents lists the clusters, serverless instances, federated database instances, private endpoint
+// services, network peering connections and containers of the project
+func listDependents(atlasV2 *admin.APIClient, projectID string) (*projectDependents, *http.Response, error) {
+	ctx := context.Background()
+	d := new(projectDependents)
+
+	var res *http.Response
+	var err error
+	d.clusters, res, err = util.ListAll(itemsPerPage, func(pageNum int) ([]admin.AdvancedClusterDescription, *http.Response, error) {
+		clusters, res, err := atlasV2.ClustersApi.ListClustersWithParams(ctx, &admin.ListClustersApiParams{
+			GroupId: projectID, ItemsPerPage: util.Pointer(itemsPerPage), PageNum: util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		return clusters.Results, res, nil
+	})
+	if err != nil {
+		return nil, res, err
+	}
+
+	d.serverlessInstances, res, err = util.ListAll(itemsPerPage, func(pageNum int) ([]admin.ServerlessInstanceDescription, *http.Response, error) {
+		instances, res, err := atlasV2.ServerlessInstancesApi.ListServerlessInstancesWithParams(ctx, &admin.ListServerlessInstancesApiParams{
+			GroupId: projectID, ItemsPerPage: util.Pointer(itemsPerPage), PageNum: util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		return instances.Results, res, nil
+	})
+	if err != nil {
+		return nil, res, err
+	}
+
+	federatedDatabases, res, err := atlasV2.DataFederationApi.ListFederatedDatabases(ctx, projectID).Execute()
+	if err != nil {
+		return nil, res, err
+	}
+	d.federatedDatabases = federatedDatabases
+
+	for _, provider := range cloudProviders {
+		endpointServices, res, err := atlasV2.PrivateEndpointServicesApi.ListPrivateEndpointServices(ctx, projectID, provider).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		d.privateEndpoints = append(d.privateEndpoints, endpointServices...)
+
+		peers, res, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.BaseNetworkPeeringConnectionSettings, *http.Response, error) {
+			peers, res, err := atlasV2.NetworkPeeringApi.ListPeeringConnectionsWithParams(ctx, &admin.ListPeeringConnectionsApiParams{
+				GroupId: projectID, ProviderName: util.Pointer(provider), ItemsPerPage: util.Pointer(itemsPerPage), PageNum: util.Pointer(pageNum),
+			}).Execute()
+			if err != nil {
+				return nil, res, err
+			}
+			return peers.Results, res, nil
+		})
+		if err != nil {
+			return nil, res, err
+		}
+		d.peeringConnections = append(d.peeringConnections, peers...)
+
+		containers, res, err := util.ListAll(itemsPerPage, func(pageNum int) ([]admin.CloudProviderContainer, *http.Response, error) {
+			containers, res, err := atlasV2.NetworkPeeringApi.ListPeeringContainerByCloudProviderWithParams(ctx, &admin.ListPeeringContainerByCloudProviderApiParams{
+				GroupId: projectID, ProviderName: util.Pointer(provider), ItemsPerPage: util.Pointer(itemsPerPage), PageNum: util.Pointer(pageNum),
+			}).Execute()
+			if err != nil {
+				return nil, res, err
+			}
+			return containers.Results, res, nil
+		})
+		if err != nil {
+			return nil, res, err
+		}
+		d.containers = append(d.containers, containers...)
+	}
+	return d, res, nil
+}
+
+func isDeleting(state *string) bool {
+	switch util.SafeString(state) {
+	case "DELETING", "DELETED", "TERMINATING":
+		return true
+	}
+	return false
+}
+
+// destroyDependents starts the termination of the first group of dependents that still exists, in the order
+// clusters and serverless instances, federated database instances, private endpoints, network peering
+// connections and containers. The caller polls until listDependents returns nothing.
+func destroyDependents(atlasV2 *admin.APIClient, projectID string, d *projectDependents) (*http.Response, error) {
+	ctx := context.Background()
+
+	if len(d.clusters)+len(d.serverlessInstances) > 0 {
+		for i := range d.clusters {
+			if isDeleting(d.clusters[i].StateName) {
+				continue
+			}
+			_, _ = logger.Debugf("ForceDestroy: deleting cluster %s", util.SafeString(d.clusters[i].Name))
+			if res, err := atlasV2.ClustersApi.DeleteCluster(ctx, projectID, util.SafeString(d.clusters[i].Name)).Execute(); err != nil {
+				return res, fmt.Errorf("error deleting cluster %s: %w", util.SafeString(d.clusters[i].Name), err)
+			}
+		}
+		for i := range d.serverlessInstances {
+			if isDeleting(d.serverlessInstances[i].StateName) {
+				continue
+			}
+			_, _ = logger.Debugf("ForceDestroy: deleting serverless instance %s", util.SafeString(d.serverlessInstances[i].Name))
+			if _, res, err := atlasV2.ServerlessInstancesApi.DeleteServerlessInstance(ctx, projectID, util.SafeString(d.serverlessInstances[i].Name)).Execute(); err != nil {
+				return res, fmt.Errorf("error deleting serverless instance %s: %w", util.SafeString(d.serverlessInstances[i].Name), err)
+			}
+		}
+		return nil, nil
+	}
+
+	if len(d.federatedDatabases) > 0 {
+		for i := range d.federatedDatabases {
+			_, _ = logger.Debugf("ForceDestroy: deleting federated database instance %s", util.SafeString(d.federatedDatabases[i].Name))
+			if _, res, err := atlasV2.DataFederationApi.DeleteFederatedDatabase(ctx, projectID, util.SafeString(d.federatedDatabases[i].Name)).Execute(); err != nil {
+				return res, fmt.Errorf("error deleting federated database instance %s: %w", util.SafeString(d.federatedDatabases[i].Name), err)
+			}
+		}
+		return nil, nil
+	}
+
+	if len(d.privateEndpoints) > 0 {
+		for i := range d.privateEndpoints {
+			if res, err := destroyPrivateEndpointService(atlasV2, projectID, &d.privateEndpoints[i]); err != nil {
+				return res, err
+			}
+		}
+		return nil, nil
+	}
+
+	if len(d.peeringConnections) > 0 {
+		for i := range d.peeringConnections {
+			peer := &d.peeringConnections[i]
+			if isDeleting(peer.StatusName) || isDeleting(peer.Status) {
+				continue
+			}
+			_, _ = logger.Debugf("ForceDestroy: deleting network peering connection %s", util.SafeString(peer.Id))
+			if _, res, err := atlasV2.NetworkPeeringApi.DeletePeeringConnection(ctx, projectID, util.SafeString(peer.Id)).Execute(); err != nil {
+				return res, fmt.Errorf("error deleting network peering connection %s: %w", util.SafeString(peer.Id), err)
+			}
+		}
+		return nil, nil
+	}
+
+	for i := range d.containers {
+		_, _ = logger.Debugf("ForceDestroy: deleting network peering container %s", util.SafeString(d.containers[i].Id))
+		if _, res, err := atlasV2.NetworkPeeringApi.DeletePeeringContainer(ctx, projectID, util.SafeString(d.containers[i].Id)).Execute(); err != nil {
+			return res, fmt.Errorf("error deleting network peering container %s: %w", util.SafeString(d.containers[i].Id), err)
+		}
+	}
+	return nil, nil
+}
+
+// destroyPrivateEndpointService removes the private endpoints of the service first, the service itself can
+// only be deleted once it has none left
+func destroyPrivateEndpointService(atlasV2 *admin.APIClient, projectID string, service *admin.EndpointService) (*http.Response, error) {
+	if isDeleting(service.Status) {
+		return nil, nil
+	}
+
+	serviceID := util.SafeString(service.Id)
+	var endpoints []string
+	endpoints = append(endpoints, service.InterfaceEndpoints...)
+	endpoints = append(endpoints, service.PrivateEndpoints...)
+	endpoints = append(endpoints, service.EndpointGroupNames...)
+	for _, endpointID := range endpoints {
+		_, _ = logger.Debugf("ForceDestroy: deleting private endpoint %s of service %s", endpointID, serviceID)
+		_, res, err := atlasV2.PrivateEndpointServicesApi.DeletePrivateEndpoint(context.Background(), projectID, service.CloudProvider, endpointID, serviceID).Execute()
+		// an endpoint that is already being deleted is rejected with a client error, it is picked up on the next poll
+		if err != nil && (res == nil || res.StatusCode >= http.StatusInternalServerError) {
+			return res, fmt.Errorf("error deleting private endpoint %s: %w", endpointID, err)
+		}
+	}
+	if len(endpoints) > 0 {
+		return nil, nil
+	}
+
+	_, _ = logger.Debugf("ForceDestroy: deleting private endpoint service %s", serviceID)
+	_, res, err := atlasV2.PrivateEndpointServicesApi.DeletePrivateEndpointService(context.Background(), projectID, service.CloudProvider, serviceID).Execute()
+	if err != nil {
+		return res, fmt.Errorf("error deleting private endpoint service %s: %w", serviceID, err)
+	}
+	return res, nil
+}
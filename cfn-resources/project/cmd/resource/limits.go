@@ -0,0 +1,110 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+func validateLimits(limits []Limit) error {
+	seen := map[string]bool{}
+	for _, limit := range limits {
+		if !util.IsStringPresent(limit.Name) || limit.Value == nil {
+			return fmt.Errorf("every entry of Limits requires Name and Value")
+		}
+		if seen[*limit.Name] {
+			return fmt.Errorf("limit %s is declared more than once", *limit.Name)
+		}
+		seen[*limit.Name] = true
+	}
+	return nil
+}
+
+// isCustomized reports whether the limit was changed from its default value. A limit without a default value is
+// not known to be customized.
+func isCustomized(limit *admin.DataFederationLimit) bool {
+	return limit.DefaultLimit != nil && limit.Value != *limit.DefaultLimit
+}
+
+// readLimits returns the limits of the project that are declared in the model or differ from their default
+func readLimits(atlasV2 *admin.APIClient, projectID string, declared []Limit) ([]Limit, *http.Response, error) {
+	declaredNames := map[string]bool{}
+	for _, limit := range declared {
+		declaredNames[util.SafeString(limit.Name)] = true
+	}
+
+	liveLimits, res, err := atlasV2.ProjectsApi.ListProjectLimits(context.Background(), projectID).Execute()
+	if err != nil {
+		return nil, res, err
+	}
+
+	var limits []Limit
+	for i := range liveLimits {
+		if declaredNames[liveLimits[i].Name] || isCustomized(&liveLimits[i]) {
+			limits = append(limits, Limit{Name: util.Pointer(liveLimits[i].Name), Value: util.Pointer(int(liveLimits[i].Value))})
+		}
+	}
+	sort.Slice(limits, func(i, j int) bool { return *limits[i].Name < *limits[j].Name })
+	return limits, res, nil
+}
+
+// reconcileLimits sets the declared limits and resets every other customized or previously declared limit to its
+// default
+func reconcileLimits(atlasV2 *admin.APIClient, projectID string, desired, previous []Limit) (*http.Response, error) {
+	liveLimits, res, err := atlasV2.ProjectsApi.ListProjectLimits(context.Background(), projectID).Execute()
+	if err != nil {
+		return res, err
+	}
+	live := map[string]*admin.DataFederationLimit{}
+	for i := range liveLimits {
+		live[liveLimits[i].Name] = &liveLimits[i]
+	}
+
+	desiredNames := map[string]bool{}
+	for _, limit := range desired {
+		desiredNames[*limit.Name] = true
+		if current, ok := live[*limit.Name]; ok && current.Value == int64(*limit.Value) {
+			continue
+		}
+		_, res, err = atlasV2.ProjectsApi.SetProjectLimit(context.Background(), *limit.Name, projectID, &admin.DataFederationLimit{
+			Name:  *limit.Name,
+			Value: int64(*limit.Value),
+		}).Execute()
+		if err != nil {
+			return res, fmt.Errorf("error setting limit %s: %w", *limit.Name, err)
+		}
+	}
+
+	previousNames := map[string]bool{}
+	for _, limit := range previous {
+		previousNames[util.SafeString(limit.Name)] = true
+	}
+	for name, limit := range live {
+		if desiredNames[name] || (!isCustomized(limit) && !previousNames[name]) {
+			continue
+		}
+		_, res, err = atlasV2.ProjectsApi.DeleteProjectLimit(context.Background(), name, projectID).Execute()
+		if err != nil {
+			return res, fmt.Errorf("error removing limit %s: %w", name, err)
+		}
+	}
+	return res, nil
+}
@@ -4,18 +4,21 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	Name                      *string          `json:",omitempty"`
-	OrgId                     *string          `json:",omitempty"`
-	ProjectOwnerId            *string          `json:",omitempty"`
-	WithDefaultAlertsSettings *bool            `json:",omitempty"`
-	Id                        *string          `json:",omitempty"`
-	Created                   *string          `json:",omitempty"`
-	ClusterCount              *int             `json:",omitempty"`
-	ProjectSettings           *ProjectSettings `json:",omitempty"`
-	Profile                   *string          `json:",omitempty"`
-	ProjectTeams              []ProjectTeam    `json:",omitempty"`
-	ProjectApiKeys            []ProjectApiKey  `json:",omitempty"`
-	RegionUsageRestrictions   *string          `json:",omitempty"`
+	Name                      *string           `json:",omitempty"`
+	OrgId                     *string           `json:",omitempty"`
+	ProjectOwnerId            *string           `json:",omitempty"`
+	WithDefaultAlertsSettings *bool             `json:",omitempty"`
+	Id                        *string           `json:",omitempty"`
+	Created                   *string           `json:",omitempty"`
+	ClusterCount              *int              `json:",omitempty"`
+	ProjectSettings           *ProjectSettings  `json:",omitempty"`
+	Profile                   *string           `json:",omitempty"`
+	ProjectTeams              []ProjectTeam     `json:",omitempty"`
+	ProjectApiKeys            []ProjectApiKey   `json:",omitempty"`
+	RegionUsageRestrictions   *string           `json:",omitempty"`
+	Limits                    []Limit           `json:",omitempty"`
+	Tags                      map[string]string `json:",omitempty"`
+	ForceDestroy              *bool             `json:",omitempty"`
 }
 
 // ProjectSettings is autogenerated from the json schema
@@ -39,3 +42,9 @@ type ProjectApiKey struct {
 	Key       *string  `json:",omitempty"`
 	RoleNames []string `json:",omitempty"`
 }
+
+// Limit is autogenerated from the json schema
+type Limit struct {
+	Name  *string `json:",omitempty"`
+	Value *int    `json:",omitempty"`
+}
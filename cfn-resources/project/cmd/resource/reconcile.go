@@ -0,0 +1,257 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	itemsPerPage = 500
+
+	memberTeam   = "team"
+	memberAPIKey = "API key"
+
+	actionAdd    = "add"
+	actionUpdate = "update"
+	actionRemove = "remove"
+)
+
+// accessChange is one step of the plan that brings the teams and API keys of a project in line with the model.
+// The live roles are kept so the step can be reverted.
+type accessChange struct {
+	member        string
+	id            string
+	action        string
+	roles         []string
+	previousRoles []string
+}
+
+func (c *accessChange) String() string {
+	switch c.action {
+	case actionRemove:
+		return fmt.Sprintf("remove %s %s", c.member, c.id)
+	default:
+		return fmt.Sprintf("%s %s %s with roles [%s]", c.action, c.member, c.id, strings.Join(c.roles, ", "))
+	}
+}
+
+func (c *accessChange) apply(atlasV2 *admin.APIClient, projectID string) (*http.Response, error) {
+	switch c.action {
+	case actionRemove:
+		return removeMember(atlasV2, projectID, c.member, c.id)
+	case actionAdd:
+		return addMember(atlasV2, projectID, c.member, c.id, c.roles)
+	default:
+		return setMemberRoles(atlasV2, projectID, c.member, c.id, c.roles)
+	}
+}
+
+// revert undoes an applied step: added members are removed, removed members are added back and
+// updated members get their previous roles again
+func (c *accessChange) revert(atlasV2 *admin.APIClient, projectID string) (*http.Response, error) {
+	switch c.action {
+	case actionRemove:
+		return addMember(atlasV2, projectID, c.member, c.id, c.previousRoles)
+	case actionAdd:
+		return removeMember(atlasV2, projectID, c.member, c.id)
+	default:
+		return setMemberRoles(atlasV2, projectID, c.member, c.id, c.previousRoles)
+	}
+}
+
+func addMember(atlasV2 *admin.APIClient, projectID, member, id string, roles []string) (*http.Response, error) {
+	if member == memberTeam {
+		_, res, err := atlasV2.TeamsApi.AddAllTeamsToProject(context.Background(), projectID, &[]admin.TeamRole{{TeamId: &id, RoleNames: roles}}).Execute()
+		return res, err
+	}
+	return setMemberRoles(atlasV2, projectID, member, id, roles)
+}
+
+func setMemberRoles(atlasV2 *admin.APIClient, projectID, member, id string, roles []string) (*http.Response, error) {
+	if member == memberTeam {
+		_, res, err := atlasV2.TeamsApi.UpdateTeamRoles(context.Background(), projectID, id, &admin.TeamRole{RoleNames: roles}).Execute()
+		return res, err
+	}
+	_, res, err := atlasV2.ProgrammaticAPIKeysApi.UpdateApiKeyRoles(context.Background(), projectID, id, &admin.UpdateAtlasProjectApiKey{Roles: roles}).Execute()
+	return res, err
+}
+
+func removeMember(atlasV2 *admin.APIClient, projectID, member, id string) (*http.Response, error) {
+	if member == memberTeam {
+		return atlasV2.TeamsApi.RemoveProjectTeam(context.Background(), projectID, id).Execute()
+	}
+	_, res, err := atlasV2.ProgrammaticAPIKeysApi.RemoveProjectApiKey(context.Background(), projectID, id).Execute()
+	return res, err
+}
+
+// listAllProjectTeams pages through all the teams assigned to the project
+func listAllProjectTeams(atlasV2 *admin.APIClient, projectID string) ([]admin.TeamRole, *http.Response, error) {
+	return util.ListAll(itemsPerPage, func(pageNum int) ([]admin.TeamRole, *http.Response, error) {
+		page, res, err := atlasV2.TeamsApi.ListProjectTeamsWithParams(context.Background(), &admin.ListProjectTeamsApiParams{
+			GroupId:      projectID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		return page.Results, res, nil
+	})
+}
+
+// listAllProjectAPIKeys pages through all the API keys assigned to the project
+func listAllProjectAPIKeys(atlasV2 *admin.APIClient, projectID string) ([]admin.ApiKeyUserDetails, *http.Response, error) {
+	return util.ListAll(itemsPerPage, func(pageNum int) ([]admin.ApiKeyUserDetails, *http.Response, error) {
+		page, res, err := atlasV2.ProgrammaticAPIKeysApi.ListProjectApiKeysWithParams(context.Background(), &admin.ListProjectApiKeysApiParams{
+			GroupId:      projectID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		return page.Results, res, nil
+	})
+}
+
+// planMembers diffs the desired members against the live ones. Members whose roles already match are left out.
+func planMembers(member string, desired, live map[string][]string) []accessChange {
+	var plan []accessChange
+	for _, id := range sortedKeys(desired) {
+		liveRoles, exists := live[id]
+		switch {
+		case !exists:
+			plan = append(plan, accessChange{member: member, id: id, action: actionAdd, roles: desired[id]})
+		case !areRolesEqual(desired[id], liveRoles):
+			plan = append(plan, accessChange{member: member, id: id, action: actionUpdate, roles: desired[id], previousRoles: liveRoles})
+		}
+	}
+	for _, id := range sortedKeys(live) {
+		if _, exists := desired[id]; !exists {
+			plan = append(plan, accessChange{member: member, id: id, action: actionRemove, previousRoles: live[id]})
+		}
+	}
+	return plan
+}
+
+func planTeams(desired []ProjectTeam, live []admin.TeamRole) []accessChange {
+	desiredRoles := map[string][]string{}
+	for _, team := range desired {
+		if util.IsStringPresent(team.TeamId) {
+			desiredRoles[*team.TeamId] = team.RoleNames
+		}
+	}
+	liveRoles := map[string][]string{}
+	for _, team := range live {
+		if util.IsStringPresent(team.TeamId) {
+			liveRoles[*team.TeamId] = team.RoleNames
+		}
+	}
+	return planMembers(memberTeam, desiredRoles, liveRoles)
+}
+
+func planAPIKeys(projectID string, desired []ProjectApiKey, live []admin.ApiKeyUserDetails) []accessChange {
+	desiredRoles := map[string][]string{}
+	for _, key := range desired {
+		if util.IsStringPresent(key.Key) {
+			desiredRoles[*key.Key] = key.RoleNames
+		}
+	}
+	liveRoles := map[string][]string{}
+	for _, key := range live {
+		if !util.IsStringPresent(key.Id) {
+			continue
+		}
+		// Consider only the roles of this project
+		for _, role := range key.Roles {
+			if util.AreStringPtrEqual(role.GroupId, &projectID) && role.RoleName != nil {
+				liveRoles[*key.Id] = append(liveRoles[*key.Id], *role.RoleName)
+			}
+		}
+	}
+	return planMembers(memberAPIKey, desiredRoles, liveRoles)
+}
+
+// orderPlan applies additions first and removals last, so members are never left without access in between
+func orderPlan(plan []accessChange) []accessChange {
+	order := map[string]int{actionAdd: 0, actionUpdate: 1, actionRemove: 2}
+	sort.SliceStable(plan, func(i, j int) bool {
+		return order[plan[i].action] < order[plan[j].action]
+	})
+	return plan
+}
+
+// applyPlan runs the plan step by step. When a step fails the steps already applied are reverted in reverse
+// order, and the returned report lists the outcome of every change. rolledBack tells whether all of them were reverted.
+func applyPlan(atlasV2 *admin.APIClient, projectID string, plan []accessChange) (report []string, rolledBack bool, res *http.Response, err error) {
+	for i := range plan {
+		res, err = plan[i].apply(atlasV2, projectID)
+		if err == nil {
+			continue
+		}
+		_, _ = logger.Warnf("ProjectId : %s, %s failed: %s", projectID, plan[i].String(), err)
+
+		report = make([]string, len(plan))
+		rolledBack = true
+		for j := i - 1; j >= 0; j-- {
+			if _, revertErr := plan[j].revert(atlasV2, projectID); revertErr != nil {
+				report[j] = fmt.Sprintf("%s: applied, rollback failed: %s", plan[j].String(), revertErr)
+				rolledBack = false
+				continue
+			}
+			report[j] = fmt.Sprintf("%s: rolled back", plan[j].String())
+		}
+		report[i] = fmt.Sprintf("%s: failed: %s", plan[i].String(), err)
+		for j := i + 1; j < len(plan); j++ {
+			report[j] = fmt.Sprintf("%s: not applied", plan[j].String())
+		}
+		return report, rolledBack, res, err
+	}
+	return nil, false, res, nil
+}
+
+func areRolesEqual(roles1, roles2 []string) bool {
+	if len(roles1) != len(roles2) {
+		return false
+	}
+	sorted1 := append([]string(nil), roles1...)
+	sorted2 := append([]string(nil), roles2...)
+	sort.Strings(sorted1)
+	sort.Strings(sorted2)
+	for i := range sorted1 {
+		if sorted1[i] != sorted2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
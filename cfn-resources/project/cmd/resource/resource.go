@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
 	"github.com/aws/aws-sdk-go/aws"
@@ -34,11 +35,6 @@ import (
 var CreateRequiredFields = []string{constants.OrgID, constants.Name}
 var UpdateRequiredFields = []string{constants.ID}
 
-type UpdateAPIKey struct {
-	Key           string
-	UpdatePayload *admin.UpdateAtlasProjectApiKey
-}
-
 func setup() {
 	util.SetupLogger("mongodb-atlas-project")
 }
@@ -57,6 +53,9 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		_, _ = logger.Warnf("Validation Error")
 		return *errEvent, nil
 	}
+	if err := validateLimits(currentModel.Limits); err != nil {
+		return progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
 
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
@@ -116,6 +115,22 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		}
 	}
 
+	// Limits and tags
+	if len(currentModel.Limits) > 0 {
+		res, err = reconcileLimits(atlasV2, *project.Id, currentModel.Limits, nil)
+		if err != nil {
+			_, _ = logger.Warnf("SetProjectLimit Error: %s", err)
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while setting project limits : %s", err.Error()), res), nil
+		}
+	}
+	if len(currentModel.Tags) > 0 {
+		res, err = updateTags(client, *project.Id, currentModel.Tags)
+		if err != nil {
+			_, _ = logger.Warnf("UpdateProject Error: %s", err)
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while setting project tags : %s", err.Error()), res), nil
+		}
+	}
+
 	formattedCreated := util.TimeToString(project.Created)
 
 	currentModel.Id = project.Id
@@ -131,6 +146,9 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		_, _ = logger.Warnf("getProject Error: %s", err)
 		return event, err
 	}
+	if event, err = readLimitsAndTags(client, proj); err != nil {
+		return event, nil
+	}
 
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
@@ -179,6 +197,9 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 	if err != nil {
 		return event, nil
 	}
+	if event, err = readLimitsAndTags(client, model); err != nil {
+		return event, nil
+	}
 
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
@@ -195,6 +216,9 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (event h
 		_, _ = logger.Warnf("Validation Error")
 		return *errEvent, nil
 	}
+	if err := validateLimits(currentModel.Limits); err != nil {
+		return progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeInvalidRequest), nil
+	}
 
 	if currentModel.Profile == nil || *currentModel.Profile == "" {
 		currentModel.Profile = aws.String(profile.DefaultProfile)
@@ -216,105 +240,50 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (event h
 		return event, nil
 	}
 
+	// Plan all team and API key changes up front, then apply them with rollback on failure
+	var plan []accessChange
 	if currentModel.ProjectTeams != nil {
-		// Get teams from project
-		teamsAssigned, _, err := atlasV2.TeamsApi.ListProjectTeams(context.Background(), projectID).Execute()
+		teamsAssigned, res, err := listAllProjectTeams(atlasV2, projectID)
 		if err != nil {
 			_, _ = logger.Warnf("ProjectId : %s, Error: %s", projectID, err)
-			return handler.ProgressEvent{
-				OperationStatus:  handler.Failed,
-				Message:          "Error while finding teams in project",
-				HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-		}
-		newTeams, changedTeams, removeTeams := getChangeInTeams(currentModel.ProjectTeams, teamsAssigned.Results)
-
-		// Remove Teams
-		for _, team := range removeTeams {
-			_, err = atlasV2.TeamsApi.RemoveProjectTeam(context.Background(), projectID, util.SafeString(team.TeamId)).Execute()
-			if err != nil {
-				_, _ = logger.Warnf("ProjectId : %s, Error: %s", projectID, err)
-				return handler.ProgressEvent{
-					OperationStatus:  handler.Failed,
-					Message:          "Error while deleting team from project",
-					HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-			}
-		}
-		// Add Teams
-		if len(newTeams) > 0 {
-			_, _, err = atlasV2.TeamsApi.AddAllTeamsToProject(context.Background(), projectID, &newTeams).Execute()
-			if err != nil {
-				_, _ = logger.Warnf("Error: %s", err)
-				return handler.ProgressEvent{
-					OperationStatus:  handler.Failed,
-					Message:          "Error while adding team to project",
-					HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-			}
-		}
-		// Update Teams
-		for _, team := range changedTeams {
-			_, _, err = atlasV2.TeamsApi.UpdateTeamRoles(context.Background(), projectID, util.SafeString(team.TeamId), &admin.TeamRole{RoleNames: team.RoleNames}).Execute()
-			if err != nil {
-				_, _ = logger.Warnf("Error: %s", err)
-				return handler.ProgressEvent{
-					OperationStatus:  handler.Failed,
-					Message:          "Error while updating team roles in project",
-					HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-			}
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while finding teams in project : %s", err.Error()), res), nil
 		}
+		plan = append(plan, planTeams(currentModel.ProjectTeams, teamsAssigned)...)
 	}
 
 	if currentModel.ProjectApiKeys != nil {
-		// Get APIKeys from project
-		itemsPerPage := 1000
-		projectAPIKeys, _, err := atlasV2.ProgrammaticAPIKeysApi.ListProjectApiKeysWithParams(context.Background(), &admin.ListProjectApiKeysApiParams{
-			GroupId:      projectID,
-			ItemsPerPage: &itemsPerPage,
-		}).Execute()
+		projectAPIKeys, res, err := listAllProjectAPIKeys(atlasV2, projectID)
 		if err != nil {
 			_, _ = logger.Warnf("ProjectId : %s, Error: %s", projectID, err)
-			return handler.ProgressEvent{
-				OperationStatus:  handler.Failed,
-				Message:          "Error while finding api keys in project",
-				HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while finding api keys in project : %s", err.Error()), res), nil
 		}
+		plan = append(plan, planAPIKeys(projectID, currentModel.ProjectApiKeys, projectAPIKeys)...)
+	}
 
-		// Get Change in ApiKeys
-		newAPIKeys, changedKeys, removeKeys := getChangeInAPIKeys(*currentModel.Id, currentModel.ProjectApiKeys, projectAPIKeys.Results)
-
-		// Remove old keys
-		for _, key := range removeKeys {
-			_, _, err = atlasV2.ProgrammaticAPIKeysApi.RemoveProjectApiKey(context.Background(), projectID, key.Key).Execute()
-			if err != nil {
-				_, _ = logger.Warnf("Error: %s", err)
-				return handler.ProgressEvent{
-					OperationStatus:  handler.Failed,
-					Message:          "Error while Un-assigning Key to project",
-					HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-			}
+	if report, rolledBack, res, err := applyPlan(atlasV2, projectID, orderPlan(plan)); err != nil {
+		outcome := "no change was kept"
+		if !rolledBack {
+			outcome = "some changes could not be rolled back"
 		}
+		return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while updating teams and api keys of project, %s:\n%s",
+			outcome, strings.Join(report, "\n")), res), nil
+	}
 
-		// Add Keys
-		for _, key := range newAPIKeys {
-			_, _, err := atlasV2.ProgrammaticAPIKeysApi.UpdateApiKeyRoles(context.Background(), projectID, key.Key, key.UpdatePayload).Execute()
-			if err != nil {
-				_, _ = logger.Warnf("Error: %s", err)
-				return handler.ProgressEvent{
-					OperationStatus:  handler.Failed,
-					Message:          "Error while Assigning Key to project",
-					HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-			}
+	// Limits and tags are removed when they are no longer declared
+	if currentModel.Limits != nil || (prevModel != nil && prevModel.Limits != nil) {
+		var previousLimits []Limit
+		if prevModel != nil {
+			previousLimits = prevModel.Limits
 		}
-
-		// Update Key Roles
-		for _, key := range changedKeys {
-			_, _, err := atlasV2.ProgrammaticAPIKeysApi.UpdateApiKeyRoles(context.Background(), projectID, key.Key, key.UpdatePayload).Execute()
-			if err != nil {
-				_, _ = logger.Warnf("Error: %s", err)
-				return handler.ProgressEvent{
-					OperationStatus:  handler.Failed,
-					Message:          "Error while Assigning Key to project",
-					HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}, nil
-			}
+		if res, err := reconcileLimits(atlasV2, projectID, currentModel.Limits, previousLimits); err != nil {
+			_, _ = logger.Warnf("ProjectId : %s, Error: %s", projectID, err)
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while updating project limits : %s", err.Error()), res), nil
+		}
+	}
+	if currentModel.Tags != nil || (prevModel != nil && prevModel.Tags != nil) {
+		if res, err := updateTags(client, projectID, currentModel.Tags); err != nil {
+			_, _ = logger.Warnf("ProjectId : %s, Error: %s", projectID, err)
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Error while updating project tags : %s", err.Error()), res), nil
 		}
 	}
 
@@ -327,6 +296,9 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (event h
 	if err != nil {
 		return event, err
 	}
+	if event, err = readLimitsAndTags(client, project); err != nil {
+		return event, nil
+	}
 
 	return handler.ProgressEvent{
 		OperationStatus: handler.Success,
@@ -357,9 +329,38 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (event h
 	if err != nil {
 		return event, nil
 	}
+
+	// Atlas rejects the deletion while dependents exist, report them or terminate them with ForceDestroy
+	dependents, res, err := listDependents(atlasV2, id)
+	if err != nil {
+		_, _ = logger.Warnf("error listing dependents of project with id(%s): %s", id, err)
+		return progressevent.GetFailedEventByResponse(fmt.Sprintf("Failed to list project dependents : %s", err.Error()), res), nil
+	}
+	forceDestroy := currentModel.ForceDestroy != nil && *currentModel.ForceDestroy
+	if dependents.blocking() && !forceDestroy {
+		return progressevent.GetFailedEventByCode(fmt.Sprintf("Project %s can't be deleted while it still has dependents (%s). "+
+			"Delete them first or set ForceDestroy to terminate them with the project", id, dependents.String()),
+			cloudformation.HandlerErrorCodeResourceConflict), nil
+	}
+	if forceDestroy && (dependents.blocking() || len(dependents.containers) > 0) {
+		if res, err := destroyDependents(atlasV2, id, dependents); err != nil {
+			_, _ = logger.Warnf("ForceDestroy error for project with id(%s): %s", id, err)
+			return progressevent.GetFailedEventByResponse(fmt.Sprintf("Failed to delete project dependents : %s", err.Error()), res), nil
+		}
+		return handler.ProgressEvent{
+			OperationStatus:      handler.InProgress,
+			Message:              fmt.Sprintf("Deleting project dependents: %s", dependents.String()),
+			ResourceModel:        currentModel,
+			CallbackDelaySeconds: dependentsCallbackSeconds,
+			CallbackContext: map[string]interface{}{
+				constants.StateName: deletingDependents,
+			},
+		}, nil
+	}
+
 	_, _ = logger.Debugf("Deleting project with id(%s)", id)
 
-	_, res, err := atlasV2.ProjectsApi.DeleteProject(context.Background(), id).Execute()
+	_, res, err = atlasV2.ProjectsApi.DeleteProject(context.Background(), id).Execute()
 	if err != nil {
 		_, _ = logger.Warnf("####error deleting project with id(%s): %s", id, err)
 		return progressevent.GetFailedEventByResponse(fmt.Sprintf("Failed to Create Project : %s", err.Error()),
@@ -418,6 +419,24 @@ func getProjectWithSettings(atlasV2 *admin.APIClient, currentModel *Model) (even
 	return handler.ProgressEvent{}, model, nil
 }
 
+// readLimitsAndTags sets the customized limits and the tags of the project on the model
+func readLimitsAndTags(client *util.MongoDBClient, model *Model) (handler.ProgressEvent, error) {
+	limits, res, err := readLimits(client.AtlasV2, *model.Id, model.Limits)
+	if err != nil {
+		_, _ = logger.Warnf("ProjectId : %s, Error: %s", *model.Id, err)
+		return progressevent.GetFailedEventByResponse(err.Error(), res), err
+	}
+	model.Limits = limits
+
+	tags, res, err := readTags(client, *model.Id)
+	if err != nil {
+		_, _ = logger.Warnf("ProjectId : %s, Error: %s", *model.Id, err)
+		return progressevent.GetFailedEventByResponse(err.Error(), res), err
+	}
+	model.Tags = tags
+	return handler.ProgressEvent{}, nil
+}
+
 func getProjectByName(name *string, client *admin.APIClient) (event handler.ProgressEvent, model *admin.Group, err error) {
 	project, res, err := client.ProjectsApi.GetProjectByName(context.Background(), *name).Execute()
 	if err != nil {
@@ -448,7 +467,7 @@ func getProjectByID(id *string, atlasV2 *admin.APIClient) (event handler.Progres
 
 func readProjectSettings(atlasV2 *admin.APIClient, id string, currentModel *Model) (event handler.ProgressEvent, model *Model, err error) {
 	// Get teams from project
-	teamsAssigned, res, err := atlasV2.TeamsApi.ListProjectTeams(context.Background(), id).Execute()
+	teamsAssigned, res, err := listAllProjectTeams(atlasV2, id)
 	if err != nil {
 		_, _ = logger.Warnf("ProjectId : %s, Error: %s", id, err)
 		return progressevent.GetFailedEventByResponse(err.Error(),
@@ -473,7 +492,7 @@ func readProjectSettings(atlasV2 *admin.APIClient, id string, currentModel *Mode
 
 	// Set teams
 	var teams []ProjectTeam
-	for _, team := range teamsAssigned.Results {
+	for _, team := range teamsAssigned {
 		if util.IsStringPresent(team.TeamId) {
 			teams = append(teams, ProjectTeam{TeamId: team.TeamId, RoleNames: team.RoleNames})
 		}
@@ -484,84 +503,6 @@ func readProjectSettings(atlasV2 *admin.APIClient, id string, currentModel *Mode
 	return handler.ProgressEvent{}, currentModel, err
 }
 
-// Get difference in Teams
-func getChangeInTeams(currentTeams []ProjectTeam, oTeams []admin.TeamRole) (newTeams []admin.TeamRole,
-	changedTeams []admin.TeamRole, removeTeams []admin.TeamRole) {
-	for _, nTeam := range currentTeams {
-		if util.IsStringPresent(nTeam.TeamId) {
-			matched := false
-			for _, oTeam := range oTeams {
-				if util.AreStringPtrEqual(nTeam.TeamId, oTeam.TeamId) {
-					changedTeams = append(changedTeams, admin.TeamRole{TeamId: nTeam.TeamId, RoleNames: nTeam.RoleNames})
-					matched = true
-					break
-				}
-			}
-			// Add to newTeams
-			if !matched {
-				newTeams = append(newTeams, admin.TeamRole{TeamId: nTeam.TeamId, RoleNames: nTeam.RoleNames})
-			}
-		}
-	}
-
-	for _, oTeam := range oTeams {
-		if util.IsStringPresent(oTeam.TeamId) {
-			matched := false
-			for _, nTeam := range currentTeams {
-				if util.AreStringPtrEqual(nTeam.TeamId, oTeam.TeamId) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				removeTeams = append(removeTeams, admin.TeamRole{TeamId: oTeam.TeamId, RoleNames: oTeam.RoleNames})
-			}
-		}
-	}
-	return newTeams, changedTeams, removeTeams
-}
-
-// Get difference in ApiKeys
-func getChangeInAPIKeys(groupID string, currentKeys []ProjectApiKey, oKeys []admin.ApiKeyUserDetails) (newKeys, changedKeys, removeKeys []UpdateAPIKey) {
-	for _, nKey := range currentKeys {
-		if util.IsStringPresent(nKey.Key) {
-			matched := false
-			for _, oKey := range oKeys {
-				if util.AreStringPtrEqual(nKey.Key, oKey.Id) {
-					changedKeys = append(changedKeys, UpdateAPIKey{Key: *nKey.Key, UpdatePayload: &admin.UpdateAtlasProjectApiKey{Roles: nKey.RoleNames}})
-					matched = true
-					break
-				}
-			}
-			// Add to newKeys
-			if !matched {
-				newKeys = append(newKeys, UpdateAPIKey{Key: *nKey.Key, UpdatePayload: &admin.UpdateAtlasProjectApiKey{Roles: nKey.RoleNames}})
-			}
-		}
-	}
-
-	for _, oKey := range oKeys {
-		if util.IsStringPresent(oKey.Id) {
-			matched := false
-			for _, nKey := range currentKeys {
-				if util.AreStringPtrEqual(nKey.Key, oKey.Id) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				for _, role := range oKey.Roles {
-					// Consider only current ProjectRoles
-					if util.AreStringPtrEqual(role.GroupId, &groupID) {
-						removeKeys = append(removeKeys, UpdateAPIKey{Key: *oKey.Id})
-					}
-				}
-			}
-		}
-	}
-	return newKeys, changedKeys, removeKeys
-}
-
 func readTeams(teams []ProjectTeam) []admin.TeamRole {
 	var newTeams []admin.TeamRole
 	for _, t := range teams {
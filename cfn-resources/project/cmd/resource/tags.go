@@ -0,0 +1,64 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+)
+
+// projectTags is the tags part of the project in the Atlas Admin API. The atlas-sdk version in use doesn't
+// model project tags yet, so they are sent through util.CallAtlasV2API.
+type projectTags struct {
+	Tags []projectTag `json:"tags"`
+}
+
+type projectTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func projectPath(projectID string) string {
+	return fmt.Sprintf("/api/atlas/v2/groups/%s", projectID)
+}
+
+func readTags(client *util.MongoDBClient, projectID string) (map[string]string, *http.Response, error) {
+	project := new(projectTags)
+	res, err := util.CallAtlasV2API(client, http.MethodGet, projectPath(projectID), nil, project)
+	if err != nil {
+		return nil, res, err
+	}
+	if len(project.Tags) == 0 {
+		return nil, res, nil
+	}
+	tags := make(map[string]string, len(project.Tags))
+	for _, tag := range project.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags, res, nil
+}
+
+// updateTags replaces all the tags of the project, an empty map removes them
+func updateTags(client *util.MongoDBClient, projectID string, tags map[string]string) (*http.Response, error) {
+	body := projectTags{Tags: make([]projectTag, 0, len(tags))}
+	for key, value := range tags {
+		body.Tags = append(body.Tags, projectTag{Key: key, Value: value})
+	}
+	sort.Slice(body.Tags, func(i, j int) bool { return body.Tags[i].Key < body.Tags[j].Key })
+	return util.CallAtlasV2API(client, http.MethodPatch, projectPath(projectID), body, nil)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/serverless-private-endpoint/cmd/resource/enums"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	aws_utils "github.com/mongodb/mongodbatlas-cloudformation-resources/util/aws"
+	progressevents "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+)
+
+// createState is the state of the creation carried between the callbacks
+type createState struct {
+	ID                  string
+	InterfaceEndpointID string
+	Assigned            bool
+}
+
+// newCreateWorkflow creates the serverless private endpoint and waits for Atlas to reserve it. With
+// CreateAndAssignAWSPrivateEndpoint, it then creates the AWS interface endpoint and assigns it to the serverless
+// private endpoint. When a step fails, the interface endpoint and the serverless private endpoint are removed.
+func newCreateWorkflow(req handler.Request, client *util.MongoDBClient, currentModel *Model) *workflow.Workflow[createState] {
+	steps := []workflow.Step[createState]{
+		{
+			Name: string(enums.CreatingPrivateEndpoint),
+			Run: func(state *createState) (bool, error) {
+				if state.ID == "" {
+					atlasPrivateEndpoint, pe := createAtlasPrivateEndpoint(currentModel, client)
+					if pe != nil {
+						return false, workflow.EventError(pe)
+					}
+					currentModel.completeWithAtlasModel(*atlasPrivateEndpoint)
+					state.ID = util.SafeString(atlasPrivateEndpoint.Id)
+					return false, nil
+				}
+				return reachedStatus(client, currentModel, state.ID, enums.Reserved)
+			},
+			Rollback: func(state *createState) error {
+				if state.ID == "" {
+					return nil
+				}
+				return deleteAtlasPrivateEndpoint(client, currentModel, state.ID)
+			},
+		},
+	}
+	if !*currentModel.CreateAndAssignAWSPrivateEndpoint {
+		return &workflow.Workflow[createState]{Steps: steps, CallbackDelaySeconds: callbackDelayInSeconds}
+	}
+
+	steps = append(steps,
+		workflow.Step[createState]{
+			Name: string(enums.CreatingAwsPrivateEndpoint),
+			Run: func(state *createState) (bool, error) {
+				awsPrivateEndpoint, pe := createAwsPrivateEndpoint(currentModel, req, state.ID)
+				if pe != nil {
+					return false, workflow.EventError(pe)
+				}
+				state.InterfaceEndpointID = awsPrivateEndpoint.InterfaceEndpointID
+				return true, nil
+			},
+			Rollback: func(state *createState) error {
+				if state.InterfaceEndpointID == "" {
+					return nil
+				}
+				if pe := aws_utils.DeletePrivateEndpoint(req, []string{state.InterfaceEndpointID},
+					*currentModel.AwsPrivateEndpointConfigurationProperties.Region); pe != nil {
+					return errors.New(pe.Message)
+				}
+				return nil
+			},
+		},
+		workflow.Step[createState]{
+			Name: string(enums.InitiatingPrivateEndpoint),
+			Run: func(state *createState) (bool, error) {
+				if !state.Assigned {
+					if pe := assignAwsPrivateEndpoint(client, state.ID, state.InterfaceEndpointID, currentModel); pe != nil {
+						return false, workflow.EventError(pe)
+					}
+					state.Assigned = true
+					return false, nil
+				}
+				return reachedStatus(client, currentModel, state.ID, enums.Available)
+			},
+		},
+	)
+	return &workflow.Workflow[createState]{Steps: steps, CallbackDelaySeconds: callbackDelayInSeconds}
+}
+
+// reachedStatus reports whether the serverless private endpoint is in the target status, and fails once Atlas
+// reports it failed
+func reachedStatus(client *util.MongoDBClient, currentModel *Model, privateEndpointID string, targetStatus enums.AtlasPrivateEndpointStatus) (bool, error) {
+	serverlessPrivateEndpoint, response, err := client.AtlasV2.ServerlessPrivateEndpointsApi.GetServerlessPrivateEndpoint(context.Background(),
+		*currentModel.ProjectId, *currentModel.InstanceName, privateEndpointID).Execute()
+	if err != nil {
+		if response == nil {
+			return false, err
+		}
+		pe := progressevents.GetFailedEventByResponse(fmt.Sprintf("error getting Serverless Private Endpoint %s", err.Error()), response)
+		return false, workflow.EventError(&pe)
+	}
+
+	switch util.SafeString(serverlessPrivateEndpoint.Status) {
+	case string(targetStatus):
+		currentModel.completeWithAtlasModel(*serverlessPrivateEndpoint)
+		return true, nil
+	case string(enums.Failed):
+		return false, workflow.Fail(cloudformation.HandlerErrorCodeServiceInternalError,
+			fmt.Errorf("the serverless private endpoint is in a Failed status, error: %s", util.SafeString(serverlessPrivateEndpoint.ErrorMessage)))
+	default:
+		currentModel.completeWithAtlasModel(*serverlessPrivateEndpoint)
+		return false, nil
+	}
+}
+
+func deleteAtlasPrivateEndpoint(client *util.MongoDBClient, currentModel *Model, privateEndpointID string) error {
+	_, response, err := client.AtlasV2.ServerlessPrivateEndpointsApi.DeleteServerlessPrivateEndpoint(context.Background(),
+		*currentModel.ProjectId, *currentModel.InstanceName, privateEndpointID).Execute()
+	if err != nil && (response == nil || !isTenantPrivateEndpointNotFound(response)) {
+		return err
+	}
+	return nil
+}
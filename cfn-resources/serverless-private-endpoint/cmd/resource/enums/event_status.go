@@ -21,9 +21,10 @@ import (
 type EventStatus string
 
 const (
-	Init                      EventStatus = "INIT"
-	CreatingPrivateEndpoint   EventStatus = "CREATING_PRIVATE_ENDPOINT_SERVICE"
-	InitiatingPrivateEndpoint EventStatus = "INITIATING_PRIVATE_ENDPOINT_SERVICE"
+	Init                       EventStatus = "INIT"
+	CreatingPrivateEndpoint    EventStatus = "CREATING_PRIVATE_ENDPOINT_SERVICE"
+	CreatingAwsPrivateEndpoint EventStatus = "CREATING_AWS_PRIVATE_ENDPOINT"
+	InitiatingPrivateEndpoint  EventStatus = "INITIATING_PRIVATE_ENDPOINT_SERVICE"
 )
 
 func ParseEventStatus(eventStatus string) (EventStatus, error) {
@@ -40,6 +41,7 @@ func getValues() []EventStatus {
 	return []EventStatus{
 		Init,
 		CreatingPrivateEndpoint,
+		CreatingAwsPrivateEndpoint,
 		InitiatingPrivateEndpoint,
 	}
 }
@@ -42,7 +42,6 @@ var ListRequiredFields = []string{constants.ProjectID, constants.InstanceName}
 
 const (
 	id                         = "id"
-	stateName                  = "StateName"
 	endpointServiceName        = "endpoint_service_name"
 	callbackDelayInSeconds     = 5
 	AwsPrivateEndpointMetaData = "AwsPrivateEndpointMetaData"
@@ -79,52 +78,14 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		return *peErr, nil
 	}
 
-	status, pe := getProcessStatus(req)
-	if pe != nil {
+	if pe := newCreateWorkflow(req, client, currentModel).Run(req.CallbackContext, currentModel); pe != nil {
 		return *pe, nil
 	}
 
-	switch status {
-	case enums.Init:
-		atlasPrivateEndpoint, errPe := createAtlasPrivateEndpoint(currentModel, client)
-		if errPe != nil {
-			return *errPe, nil
-		}
-
-		currentModel.completeWithAtlasModel(*atlasPrivateEndpoint)
-		callbackContext := getCallbackContext(*currentModel.Id, currentModel.EndpointServiceName)
-		callbackContext[stateName] = enums.CreatingPrivateEndpoint
-
-		return progressevents.GetInProgressProgressEvent("Creating ", callbackContext, currentModel, callbackDelayInSeconds), nil
-	case enums.CreatingPrivateEndpoint:
-		progressEvent := validateCompletion(req, currentModel, client, enums.Reserved, constants.CREATE)
-		if progressEvent.OperationStatus != handler.Success {
-			progressEvent.CallbackContext = req.CallbackContext
-			return progressEvent, nil
-		}
-
-		if !*currentModel.CreateAndAssignAWSPrivateEndpoint {
-			return progressEvent, nil
-		}
-
-		currentModel = progressEvent.ResourceModel.(*Model)
-
-		awsPrivateEndpoint, peError := createAwsPrivateEndpoint(currentModel, req)
-		if peErr != nil {
-			return *peError, nil
-		}
-
-		return assignAwsPrivateEndpoint(req, client, *awsPrivateEndpoint, currentModel), nil
-
-	default:
-		progressEvent := validateCompletion(req, currentModel, client, enums.Available, constants.CREATE)
-		if progressEvent.OperationStatus == handler.InProgress {
-			progressEvent.CallbackContext = req.CallbackContext
-			progressEvent.CallbackContext[stateName] = enums.InitiatingPrivateEndpoint
-		}
-
-		return progressEvent, nil
-	}
+	return handler.ProgressEvent{
+		OperationStatus: handler.Success,
+		Message:         fmt.Sprintf("%s Completed", string(constants.CREATE)),
+		ResourceModel:   currentModel}, nil
 }
 
 func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.ProgressEvent, error) {
@@ -321,10 +282,13 @@ func List(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		ResourceModels:  ConvertListToModelList(serverlessPrivateEndpoints, currentModel.Profile, currentModel.ProjectId, currentModel.InstanceName)}, nil
 }
 
-func createAwsPrivateEndpoint(currentModel *Model, req handler.Request) (*aws_utils.PrivateEndpointOutput, *handler.ProgressEvent) {
+// createAwsPrivateEndpoint creates the interface endpoint of the serverless private endpoint, a retry returns the
+// interface endpoint of the first attempt
+func createAwsPrivateEndpoint(currentModel *Model, req handler.Request, privateEndpointID string) (*aws_utils.PrivateEndpointOutput, *handler.ProgressEvent) {
 	awsPrivateEndpointInput := aws_utils.PrivateEndpointInput{
-		VpcID:     *currentModel.AwsPrivateEndpointConfigurationProperties.VpcId,
-		SubnetIDs: currentModel.AwsPrivateEndpointConfigurationProperties.SubnetIds,
+		VpcID:       *currentModel.AwsPrivateEndpointConfigurationProperties.VpcId,
+		SubnetIDs:   currentModel.AwsPrivateEndpointConfigurationProperties.SubnetIds,
+		ClientToken: privateEndpointID,
 	}
 
 	output, errpe := aws_utils.CreatePrivateEndpoint(req, *currentModel.EndpointServiceName,
@@ -389,35 +353,35 @@ func createAtlasPrivateEndpoint(currentModel *Model, client *util.MongoDBClient)
 	return serverlessPrivateEndpoint, nil
 }
 
-func assignAwsPrivateEndpoint(req handler.Request, client *util.MongoDBClient, awsPrivateEndpoint aws_utils.PrivateEndpointOutput, currentModel *Model) handler.ProgressEvent {
+func assignAwsPrivateEndpoint(client *util.MongoDBClient, privateEndpointID, interfaceEndpointID string, currentModel *Model) *handler.ProgressEvent {
 	serverlessPrivateEndpointInput := admin.ServerlessTenantEndpointUpdate{
 		Comment:                 currentModel.Comment,
 		ProviderName:            *currentModel.ProviderName,
-		CloudProviderEndpointId: &awsPrivateEndpoint.InterfaceEndpointID,
+		CloudProviderEndpointId: &interfaceEndpointID,
 	}
 
 	createServerlessPrivateEndpointRequest := client.AtlasV2.ServerlessPrivateEndpointsApi.UpdateServerlessPrivateEndpoint(context.Background(),
-		*currentModel.ProjectId, *currentModel.InstanceName, *currentModel.Id, &serverlessPrivateEndpointInput)
+		*currentModel.ProjectId, *currentModel.InstanceName, privateEndpointID, &serverlessPrivateEndpointInput)
 	serverlessPrivateEndpoint, response, err := createServerlessPrivateEndpointRequest.Execute()
 	defer response.Body.Close()
 
 	if err != nil {
 		if isTenantPrivateEndpointNotFound(response) {
-			return progressevents.GetFailedEventByCode(fmt.Sprintf("error updating Serverless Private Endpoint %s", err.Error()), cloudformation.HandlerErrorCodeNotFound)
+			pe := progressevents.GetFailedEventByCode(fmt.Sprintf("error updating Serverless Private Endpoint %s", err.Error()), cloudformation.HandlerErrorCodeNotFound)
+			return &pe
 		}
-		return progressevents.GetFailedEventByResponse(fmt.Sprintf("error updating Serverless Private Endpoint %s",
+		pe := progressevents.GetFailedEventByResponse(fmt.Sprintf("error updating Serverless Private Endpoint %s",
 			err.Error()), response)
+		return &pe
 	}
 
 	if serverlessPrivateEndpoint == nil {
-		return progressevents.GetFailedEventByCode(fmt.Sprintf("Error while trying to make api call, CreateServerlessPrivateEndpoint returned status %d, and the response is NULL", response.StatusCode),
+		pe := progressevents.GetFailedEventByCode(fmt.Sprintf("Error while trying to make api call, CreateServerlessPrivateEndpoint returned status %d, and the response is NULL", response.StatusCode),
 			cloudformation.HandlerErrorCodeInternalFailure)
+		return &pe
 	}
 
-	callbackContext := req.CallbackContext
-	callbackContext[stateName] = enums.InitiatingPrivateEndpoint
-
-	return progressevents.GetInProgressProgressEvent("Create in progress", callbackContext, currentModel, callbackDelayInSeconds)
+	return nil
 }
 
 func isTenantPrivateEndpointNotFound(response *http.Response) bool {
@@ -550,23 +514,6 @@ func (currentModel *Model) validateAwsPrivateEndpointProperties() *handler.Progr
 	return nil
 }
 
-func getProcessStatus(req handler.Request) (enums.EventStatus, *handler.ProgressEvent) {
-	callback := req.CallbackContext[stateName]
-	if callback == nil {
-		return enums.Init, nil
-	}
-
-	eventStatus, err := enums.ParseEventStatus(fmt.Sprintf("%v", callback))
-
-	if err != nil {
-		pe := progressevents.GetFailedEventByCode(fmt.Sprintf("Error parsing callback status : %s", err.Error()),
-			cloudformation.HandlerErrorCodeServiceInternalError)
-		return "", &pe
-	}
-
-	return eventStatus, nil
-}
-
 func unmarshallAwsMetadata(input string) (createAwsPrivateEndpoint bool, region *string) {
 	parts := strings.Split(input, "/")
 	if len(parts) != 2 {
@@ -0,0 +1,269 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	atlasv2 "go.mongodb.org/atlas-sdk/v20231001001/admin"
+)
+
+const (
+	itemsPerPage = 500
+
+	// invitationRole is the organization role given to users invited through a team
+	invitationRole = "ORG_MEMBER"
+)
+
+// normalizeUsername makes usernames comparable, Atlas treats them as case-insensitive email addresses
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// listOrgUsers pages through the users of the organization and returns their IDs by normalized username
+func listOrgUsers(atlasV2 *atlasv2.APIClient, orgID string) (map[string]string, *http.Response, error) {
+	orgUsers, res, err := util.ListAll(itemsPerPage, func(pageNum int) ([]atlasv2.CloudAppUser, *http.Response, error) {
+		page, res, err := atlasV2.OrganizationsApi.ListOrganizationUsersWithParams(context.Background(), &atlasv2.ListOrganizationUsersApiParams{
+			OrgId:        orgID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		return page.Results, res, nil
+	})
+	if err != nil {
+		return nil, res, err
+	}
+
+	users := make(map[string]string, len(orgUsers))
+	for i := range orgUsers {
+		users[normalizeUsername(orgUsers[i].Username)] = util.SafeString(orgUsers[i].Id)
+	}
+	return users, res, nil
+}
+
+// listTeamUsers pages through the users of the team
+func listTeamUsers(atlasV2 *atlasv2.APIClient, orgID, teamID string) ([]atlasv2.CloudAppUser, *http.Response, error) {
+	return util.ListAll(itemsPerPage, func(pageNum int) ([]atlasv2.CloudAppUser, *http.Response, error) {
+		page, res, err := atlasV2.TeamsApi.ListTeamUsersWithParams(context.Background(), &atlasv2.ListTeamUsersApiParams{
+			OrgId:        orgID,
+			TeamId:       teamID,
+			ItemsPerPage: util.Pointer(itemsPerPage),
+			PageNum:      util.Pointer(pageNum),
+		}).Execute()
+		if err != nil {
+			return nil, res, err
+		}
+		return page.Results, res, nil
+	})
+}
+
+// listTeamInvitations returns the pending organization invitations that add the user to the team
+func listTeamInvitations(atlasV2 *atlasv2.APIClient, orgID, teamID string) ([]atlasv2.OrganizationInvitation, *http.Response, error) {
+	invitations, res, err := atlasV2.OrganizationsApi.ListOrganizationInvitations(context.Background(), orgID).Execute()
+	if err != nil {
+		return nil, res, err
+	}
+	var teamInvitations []atlasv2.OrganizationInvitation
+	for i := range invitations {
+		if hasTeam(invitations[i].TeamIds, teamID) {
+			teamInvitations = append(teamInvitations, invitations[i])
+		}
+	}
+	return teamInvitations, res, nil
+}
+
+// reconcileMembers makes usernames the exact membership of the team. Organization users are added to the
+// team directly, everyone else gets an organization invitation for the team. Users and invitations of the
+// team that are no longer listed are removed.
+func reconcileMembers(atlasV2 *atlasv2.APIClient, orgID, teamID string, usernames []string) (*http.Response, error) {
+	orgUsers, res, err := listOrgUsers(atlasV2, orgID)
+	if err != nil {
+		return res, err
+	}
+	teamUsers, res, err := listTeamUsers(atlasV2, orgID, teamID)
+	if err != nil {
+		return res, err
+	}
+	invitations, res, err := atlasV2.OrganizationsApi.ListOrganizationInvitations(context.Background(), orgID).Execute()
+	if err != nil {
+		return res, err
+	}
+
+	desired := map[string]bool{}
+	for _, username := range usernames {
+		desired[normalizeUsername(username)] = true
+	}
+	members := map[string]bool{}
+	for i := range teamUsers {
+		members[normalizeUsername(teamUsers[i].Username)] = true
+	}
+	invitationByUser := map[string]*atlasv2.OrganizationInvitation{}
+	for i := range invitations {
+		invitationByUser[normalizeUsername(util.SafeString(invitations[i].Username))] = &invitations[i]
+	}
+
+	var newUsers []atlasv2.AddUserToTeam
+	for _, username := range usernames {
+		key := normalizeUsername(username)
+		if members[key] {
+			continue
+		}
+		if userID, ok := orgUsers[key]; ok {
+			newUsers = append(newUsers, atlasv2.AddUserToTeam{Id: userID})
+			continue
+		}
+		if res, err = inviteToTeam(atlasV2, orgID, teamID, username, invitationByUser[key]); err != nil {
+			return res, err
+		}
+	}
+	if len(newUsers) > 0 {
+		if _, res, err = atlasV2.TeamsApi.AddTeamUser(context.Background(), orgID, teamID, &newUsers).Execute(); err != nil {
+			return res, err
+		}
+	}
+
+	for i := range teamUsers {
+		if desired[normalizeUsername(teamUsers[i].Username)] {
+			continue
+		}
+		_, _ = logger.Debugf("removing user %s from team %s", teamUsers[i].Username, teamID)
+		if res, err = atlasV2.TeamsApi.RemoveTeamUser(context.Background(), orgID, teamID, util.SafeString(teamUsers[i].Id)).Execute(); err != nil {
+			return res, err
+		}
+	}
+
+	for i := range invitations {
+		if !hasTeam(invitations[i].TeamIds, teamID) || desired[normalizeUsername(util.SafeString(invitations[i].Username))] {
+			continue
+		}
+		if res, err = withdrawFromInvitation(atlasV2, orgID, teamID, &invitations[i]); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// inviteToTeam creates an organization invitation for the team, or adds the team to the pending invitation
+// of the user if there is one
+func inviteToTeam(atlasV2 *atlasv2.APIClient, orgID, teamID, username string, invitation *atlasv2.OrganizationInvitation) (*http.Response, error) {
+	if invitation == nil {
+		_, _ = logger.Debugf("inviting %s to organization %s for team %s", username, orgID, teamID)
+		_, res, err := atlasV2.OrganizationsApi.CreateOrganizationInvitation(context.Background(), orgID, &atlasv2.OrganizationInvitationRequest{
+			Username: util.Pointer(username),
+			Roles:    []string{invitationRole},
+			TeamIds:  []string{teamID},
+		}).Execute()
+		return res, err
+	}
+	if hasTeam(invitation.TeamIds, teamID) {
+		return nil, nil
+	}
+
+	_, _ = logger.Debugf("adding team %s to the pending invitation of %s", teamID, username)
+	_, res, err := atlasV2.OrganizationsApi.UpdateOrganizationInvitationById(context.Background(), orgID, util.SafeString(invitation.Id), &atlasv2.OrganizationInvitationUpdateRequest{
+		Roles:   invitation.Roles,
+		TeamIds: append(append([]string(nil), invitation.TeamIds...), teamID),
+	}).Execute()
+	return res, err
+}
+
+// withdrawFromInvitation takes the team out of a pending invitation. An invitation that only existed for
+// this team is deleted.
+func withdrawFromInvitation(atlasV2 *atlasv2.APIClient, orgID, teamID string, invitation *atlasv2.OrganizationInvitation) (*http.Response, error) {
+	var teamIDs []string
+	for _, id := range invitation.TeamIds {
+		if id != teamID {
+			teamIDs = append(teamIDs, id)
+		}
+	}
+
+	if len(teamIDs) == 0 {
+		_, _ = logger.Debugf("deleting invitation %s of %s", util.SafeString(invitation.Id), util.SafeString(invitation.Username))
+		_, res, err := atlasV2.OrganizationsApi.DeleteOrganizationInvitation(context.Background(), orgID, util.SafeString(invitation.Id)).Execute()
+		if err != nil && res != nil && res.StatusCode == http.StatusNotFound {
+			return res, nil
+		}
+		return res, err
+	}
+
+	_, _ = logger.Debugf("removing team %s from invitation %s", teamID, util.SafeString(invitation.Id))
+	_, res, err := atlasV2.OrganizationsApi.UpdateOrganizationInvitationById(context.Background(), orgID, util.SafeString(invitation.Id), &atlasv2.OrganizationInvitationUpdateRequest{
+		Roles:   invitation.Roles,
+		TeamIds: teamIDs,
+	}).Execute()
+	return res, err
+}
+
+// withdrawTeamInvitations takes the team out of all pending invitations, so none point to a deleted team
+func withdrawTeamInvitations(atlasV2 *atlasv2.APIClient, orgID, teamID string) (*http.Response, error) {
+	invitations, res, err := listTeamInvitations(atlasV2, orgID, teamID)
+	if err != nil {
+		return res, err
+	}
+	for i := range invitations {
+		if res, err = withdrawFromInvitation(atlasV2, orgID, teamID, &invitations[i]); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// splitByMembership separates the usernames of organization users from the ones that still need an invitation
+func splitByMembership(usernames []string, orgUsers map[string]string) (members, invitees []string) {
+	for _, username := range usernames {
+		if _, ok := orgUsers[normalizeUsername(username)]; ok {
+			members = append(members, username)
+		} else {
+			invitees = append(invitees, username)
+		}
+	}
+	return members, invitees
+}
+
+func newPendingInvitations(invitations []atlasv2.OrganizationInvitation) []PendingInvitation {
+	pending := make([]PendingInvitation, 0, len(invitations))
+	for i := range invitations {
+		invitation := PendingInvitation{
+			Id:       invitations[i].Id,
+			Username: invitations[i].Username,
+		}
+		if invitations[i].ExpiresAt != nil {
+			invitation.ExpiresAt = util.Pointer(invitations[i].ExpiresAt.Format(time.RFC3339))
+		}
+		pending = append(pending, invitation)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return util.SafeString(pending[i].Username) < util.SafeString(pending[j].Username)
+	})
+	return pending
+}
+
+func hasTeam(teamIDs []string, teamID string) bool {
+	for _, id := range teamIDs {
+		if id == teamID {
+			return true
+		}
+	}
+	return false
+}
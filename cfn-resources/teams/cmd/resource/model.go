@@ -4,14 +4,15 @@ package resource
 
 // Model is autogenerated from the json schema
 type Model struct {
-	Profile   *string     `json:",omitempty"`
-	RoleNames []string    `json:",omitempty"`
-	OrgId     *string     `json:",omitempty"`
-	ProjectId *string     `json:",omitempty"`
-	TeamId    *string     `json:",omitempty"`
-	Name      *string     `json:",omitempty"`
-	Usernames []string    `json:",omitempty"`
-	Users     []AtlasUser `json:",omitempty"`
+	Profile            *string             `json:",omitempty"`
+	RoleNames          []string            `json:",omitempty"`
+	OrgId              *string             `json:",omitempty"`
+	ProjectId          *string             `json:",omitempty"`
+	TeamId             *string             `json:",omitempty"`
+	Name               *string             `json:",omitempty"`
+	Usernames          []string            `json:",omitempty"`
+	Users              []AtlasUser         `json:",omitempty"`
+	PendingInvitations []PendingInvitation `json:",omitempty"`
 }
 
 // AtlasUser is autogenerated from the json schema
@@ -41,3 +42,10 @@ type AtlasRole struct {
 	OrgId     *string `json:",omitempty"`
 	RoleName  *string `json:",omitempty"`
 }
+
+// PendingInvitation is autogenerated from the json schema
+type PendingInvitation struct {
+	Id        *string `json:",omitempty"`
+	Username  *string `json:",omitempty"`
+	ExpiresAt *string `json:",omitempty"`
+}
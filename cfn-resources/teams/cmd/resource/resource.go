@@ -60,10 +60,17 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 	orgID := cast.ToString(currentModel.OrgId)
 	projectID := cast.ToString(currentModel.ProjectId)
 	if teamID == "" {
+		// users that haven't joined the organization yet are invited once the team exists
+		orgUsers, resp, err := listOrgUsers(atlasV2, orgID)
+		if err != nil {
+			return progressevents.GetFailedEventByResponse(fmt.Sprintf("unable to list organization users %v", err), resp), nil
+		}
+		members, invitees := splitByMembership(currentModel.Usernames, orgUsers)
+
 		// create new team in organization
 		teamResponse, resp, err := atlasV2.TeamsApi.CreateTeam(context.Background(), orgID, &atlasv2.Team{
 			Name:      cast.ToString(currentModel.Name),
-			Usernames: currentModel.Usernames,
+			Usernames: members,
 		}).Execute()
 
 		if err != nil {
@@ -71,6 +78,13 @@ func Create(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		}
 		teamID = util.SafeString(teamResponse.Id)
 		currentModel = convertTeamToModel(teamResponse, currentModel)
+		currentModel.Usernames = append(currentModel.Usernames, invitees...)
+
+		if len(invitees) > 0 {
+			if resp, err = reconcileMembers(atlasV2, orgID, teamID, currentModel.Usernames); err != nil {
+				return progressevents.GetFailedEventByResponse(fmt.Sprintf("unable to invite users to team %v", err), resp), nil
+			}
+		}
 	}
 
 	// add existing team or newly created team to project if project id exist in the request
@@ -130,12 +144,10 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 
 	currentModel = convertTeamResponseToModel(team, currentModel)
 
-	paginatedResp, _, err := atlasV2.TeamsApi.ListTeamUsers(context.Background(), orgID, *currentModel.TeamId).Execute()
+	usersRespList, _, err := listTeamUsers(atlasV2, orgID, *currentModel.TeamId)
 	if err != nil {
 		_, _ = logger.Warnf("error getting Team user information: %v", err)
-	}
-	if paginatedResp != nil {
-		usersRespList := paginatedResp.Results
+	} else {
 		var userNames []string
 		var userList []AtlasUser
 		for ind := range usersRespList {
@@ -144,6 +156,18 @@ func Read(req handler.Request, prevModel *Model, currentModel *Model) (handler.P
 		}
 		currentModel.Usernames = userNames
 		currentModel.Users = userList
+
+		// users invited through the team are part of its declared membership until they accept. They are only
+		// added to the usernames that were just read, not to the ones of the incoming model.
+		invitations, _, err := listTeamInvitations(atlasV2, orgID, *currentModel.TeamId)
+		if err != nil {
+			_, _ = logger.Warnf("error getting Team invitations: %v", err)
+		} else {
+			currentModel.PendingInvitations = newPendingInvitations(invitations)
+			for i := range currentModel.PendingInvitations {
+				currentModel.Usernames = append(currentModel.Usernames, util.SafeString(currentModel.PendingInvitations[i].Username))
+			}
+		}
 	}
 
 	return handler.ProgressEvent{
@@ -240,42 +264,11 @@ func Update(req handler.Request, prevModel *Model, currentModel *Model) (handler
 		}
 	}
 
-	// add/remove user to/from teams
+	// add, invite and remove users so the team has exactly the declared members
 	if currentModel.Usernames != nil {
-		// get the current users list for the team
-		paginatedResp, _, err := atlasV2.TeamsApi.ListTeamUsers(context.Background(), orgID, teamID).Execute()
-		if err != nil {
-			_, _ = logger.Warnf("get assigned user to team -error (%v)", err)
-		}
-		usernames := currentModel.Usernames
-		var newUsers []atlasv2.AddUserToTeam
-		for ind := range usernames {
-			currentUser, isExistingUser := isUserExist(paginatedResp.Results, usernames[ind])
-
-			if isExistingUser {
-				// remove user from team
-				_, err := atlasV2.TeamsApi.RemoveTeamUser(context.Background(), orgID, teamID, util.SafeString(currentUser.Id)).Execute()
-				if err != nil {
-					_, _ = logger.Warnf("remove user(%s) from Team(%s) -error (%v) \n", util.SafeString(currentUser.Id), teamID, err)
-				}
-			} else {
-				// add user to team
-				user, _, err := atlasV2.MongoDBCloudUsersApi.GetUserByUsername(context.Background(), usernames[ind]).Execute()
-				if err != nil {
-					_, _ = logger.Warnf("Error reading user (%s)  with error (%v) \n", usernames[ind], err)
-				}
-				// if the user exists, we will store its ID so that we can save as user list later
-				if user != nil {
-					newUsers = append(newUsers, atlasv2.AddUserToTeam{Id: util.SafeString(user.Id)})
-				}
-			}
-		}
-		// save all new users
-		if len(newUsers) > 0 {
-			atlasV2.TeamsApi.AddTeamUser(context.Background(), orgID, teamID, &newUsers)
-			if err != nil {
-				_, _ = logger.Warnf("team -Add users error (%+v) \n", err)
-			}
+		if res, err = reconcileMembers(atlasV2, orgID, teamID, currentModel.Usernames); err != nil {
+			_, _ = logger.Warnf("reconcile users of Team(%s) -error (%v)", teamID, err)
+			return progressevents.GetFailedEventByResponse(fmt.Sprintf("unable to update the users of team %v", err), res), nil
 		}
 	}
 
@@ -373,6 +366,10 @@ func Delete(req handler.Request, prevModel *Model, currentModel *Model) (handler
 			Message:          "Resource Not Found",
 			HandlerErrorCode: cloudformation.HandlerErrorCodeNotFound}, nil
 	}
+	if res, err := withdrawTeamInvitations(atlasV2, cast.ToString(currentModel.OrgId), util.SafeString(team.Id)); err != nil {
+		return progressevents.GetFailedEventByResponse(fmt.Sprintf("unable to withdraw the invitations of team %v", err), res), nil
+	}
+
 	if currentModel.ProjectId != nil {
 		if err := removeFromProject(atlasV2, currentModel); err != nil {
 			return handler.ProgressEvent{
@@ -454,17 +451,6 @@ func getTeam(atlasV2 *atlasv2.APIClient, currentModel *Model) (*atlasv2.TeamResp
 	return nil, nil, errors.New("could not fetch Team as neither TeamId or Name were defined in model")
 }
 
-func isUserExist(users []atlasv2.CloudAppUser, username string) (atlasv2.CloudAppUser, bool) {
-	endLoop := len(users)
-	for ind := 0; ind < endLoop; ind++ {
-		_, _ = logger.Debugf("atlas user : %s,target User %s", users[ind].Username, username)
-		if users[ind].Username == username {
-			return users[ind], true
-		}
-	}
-	return atlasv2.CloudAppUser{}, false
-}
-
 func getProjectIDByTeamID(ctx context.Context, atlasV2 *atlasv2.APIClient, teamID string) (string, error) {
 	paginatedResp, _, err := atlasV2.ProjectsApi.ListProjects(context.Background()).Execute()
 	if err != nil {
@@ -0,0 +1,84 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const atlasV2DefaultVersion = "2023-01-01"
+
+// CallAtlasV2API sends a request to an Atlas Admin API v2 endpoint, or with fields, that the atlas-sdk version in use
+// doesn't model yet. It reuses the authenticated HTTP client and base URL of the SDK client. body and result are
+// marshaled as JSON and may be nil. The returned response is never nil, so it can be passed to the progress event helpers.
+func CallAtlasV2API(client *MongoDBClient, method, path string, body, result any) (*http.Response, error) {
+	return CallAtlasV2APIWithVersion(client, atlasV2DefaultVersion, method, path, body, result)
+}
+
+// CallAtlasV2APIWithVersion is CallAtlasV2API for endpoints that only exist in a later resource version of the
+// Admin API, such as 2023-11-15
+func CallAtlasV2APIWithVersion(client *MongoDBClient, version, method, path string, body, result any) (*http.Response, error) {
+	mediaType := fmt.Sprintf("application/vnd.atlas.%s+json", version)
+	internalError := &http.Response{StatusCode: http.StatusInternalServerError}
+	cfg := client.AtlasV2.GetConfig()
+	baseURL, err := cfg.ServerURL(0, nil)
+	if err != nil {
+		return internalError, err
+	}
+
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		payload, marshalErr := json.Marshal(body)
+		if marshalErr != nil {
+			return internalError, marshalErr
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), method, baseURL+path, reader)
+	if err != nil {
+		return internalError, err
+	}
+	req.Header.Set("Accept", mediaType)
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", mediaType)
+	}
+
+	response, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return internalError, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return response, err
+	}
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return response, fmt.Errorf("%s %s: %s", method, response.Status, string(responseBody))
+	}
+	if result != nil && len(responseBody) > 0 {
+		if err = json.Unmarshal(responseBody, result); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
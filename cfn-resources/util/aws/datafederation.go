@@ -0,0 +1,133 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+)
+
+const (
+	creatingDataFederationEndpoint = "CREATING_AWS_PRIVATE_ENDPOINT"
+	addingDataFederationEndpoint   = "ADDING_PRIVATE_ENDPOINT"
+)
+
+// dataFederationServiceNames are the AWS PrivateLink endpoint services of Atlas Data Federation and Online Archive,
+// see https://www.mongodb.com/docs/atlas/data-federation/tutorial/config-private-endpoint/
+var dataFederationServiceNames = map[string]string{
+	"us-east-1":      "com.amazonaws.vpce.us-east-1.vpce-svc-00e311695874992b4",
+	"us-west-2":      "com.amazonaws.vpce.us-west-2.vpce-svc-09d86b19e59d1b4bb",
+	"eu-west-1":      "com.amazonaws.vpce.eu-west-1.vpce-svc-0824460b72e1a420e",
+	"eu-west-2":      "com.amazonaws.vpce.eu-west-2.vpce-svc-052f1840aa0c4f1f9",
+	"eu-central-1":   "com.amazonaws.vpce.eu-central-1.vpce-svc-0ac8ce91871138c0d",
+	"sa-east-1":      "com.amazonaws.vpce.sa-east-1.vpce-svc-0b56e75e8cdf50044",
+	"ap-southeast-2": "com.amazonaws.vpce.ap-southeast-2.vpce-svc-036f1de74d761706e",
+	"ap-south-1":     "com.amazonaws.vpce.ap-south-1.vpce-svc-03eb8a541f96d356d",
+}
+
+// DataFederationServiceName returns the endpoint service of Data Federation in the region, given in the Atlas
+// (US_EAST_1) or the AWS (us-east-1) format. It reports false for the regions without one.
+func DataFederationServiceName(region string) (string, bool) {
+	name, ok := dataFederationServiceNames[convertToAWSRegion(region)]
+	return name, ok
+}
+
+// DataFederationEndpointConfig is the AWS interface endpoint to create for Data Federation or Online Archive
+type DataFederationEndpointConfig struct {
+	VpcID     string
+	SubnetIDs []string
+	Region    string
+	// EndpointServiceName is optional, the Data Federation endpoint service of the region is used by default
+	EndpointServiceName string
+}
+
+// Validate checks that the configuration is complete and that the endpoint service is known
+func (c *DataFederationEndpointConfig) Validate() error {
+	if c.VpcID == "" || c.Region == "" || len(c.SubnetIDs) == 0 {
+		return errors.New("VpcId, SubnetIds and Region are required")
+	}
+	_, err := c.serviceName()
+	return err
+}
+
+func (c *DataFederationEndpointConfig) serviceName() (string, error) {
+	if c.EndpointServiceName != "" {
+		return c.EndpointServiceName, nil
+	}
+	if name, ok := DataFederationServiceName(c.Region); ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("no Data Federation endpoint service is known in %s, set EndpointServiceName", c.Region)
+}
+
+// DataFederationEndpointState is the state of the creation of the interface endpoint carried between the callbacks
+type DataFederationEndpointState struct {
+	ClientToken string
+	EndpointID  string
+}
+
+// NewDataFederationEndpointWorkflow creates the AWS interface endpoint, then calls addEndpoint with its ID to add
+// it to Atlas. When adding it fails, the interface endpoint is deleted.
+func NewDataFederationEndpointWorkflow(req handler.Request, config *DataFederationEndpointConfig,
+	addEndpoint func(endpointID string) error) *workflow.Workflow[DataFederationEndpointState] {
+	return &workflow.Workflow[DataFederationEndpointState]{
+		Steps: []workflow.Step[DataFederationEndpointState]{
+			{
+				Name: creatingDataFederationEndpoint,
+				Run: func(state *DataFederationEndpointState) (bool, error) {
+					if state.ClientToken == "" {
+						state.ClientToken = NewClientToken()
+					}
+					serviceName, err := config.serviceName()
+					if err != nil {
+						return false, workflow.Fail(cloudformation.HandlerErrorCodeInvalidRequest, err)
+					}
+					output, pe := CreatePrivateEndpoint(req, serviceName, config.Region, []PrivateEndpointInput{{
+						VpcID:       config.VpcID,
+						SubnetIDs:   config.SubnetIDs,
+						ClientToken: state.ClientToken,
+					}})
+					if pe != nil {
+						return false, workflow.EventError(pe)
+					}
+					state.EndpointID = output[0].InterfaceEndpointID
+					return true, nil
+				},
+				Rollback: func(state *DataFederationEndpointState) error {
+					if state.EndpointID == "" {
+						return nil
+					}
+					if pe := DeletePrivateEndpoint(req, []string{state.EndpointID}, config.Region); pe != nil {
+						return errors.New(pe.Message)
+					}
+					return nil
+				},
+			},
+			{
+				Name: addingDataFederationEndpoint,
+				Run: func(state *DataFederationEndpointState) (bool, error) {
+					if err := addEndpoint(state.EndpointID); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws_test
+
+import (
+	"testing"
+
+	awsutil "github.com/mongodb/mongodbatlas-cloudformation-resources/util/aws"
+)
+
+func TestDataFederationServiceName(t *testing.T) {
+	const usEast1 = "com.amazonaws.vpce.us-east-1.vpce-svc-00e311695874992b4"
+	for _, region := range []string{"us-east-1", "US_EAST_1"} {
+		if name, ok := awsutil.DataFederationServiceName(region); !ok || name != usEast1 {
+			t.Errorf("%s: got %q, %v", region, name, ok)
+		}
+	}
+	if name, ok := awsutil.DataFederationServiceName("af-south-1"); ok {
+		t.Errorf("af-south-1: got %q", name)
+	}
+}
+
+func TestNewClientToken(t *testing.T) {
+	a, b := awsutil.NewClientToken(), awsutil.NewClientToken()
+	if len(a) != 32 || a == b {
+		t.Errorf("got %q and %q", a, b)
+	}
+}
+
+func TestDataFederationEndpointConfigValidate(t *testing.T) {
+	testCases := map[string]struct {
+		config  awsutil.DataFederationEndpointConfig
+		wantErr bool
+	}{
+		"known region": {
+			config: awsutil.DataFederationEndpointConfig{VpcID: "vpc-1", SubnetIDs: []string{"subnet-1"}, Region: "us-east-1"},
+		},
+		"unknown region with service name": {
+			config: awsutil.DataFederationEndpointConfig{VpcID: "vpc-1", SubnetIDs: []string{"subnet-1"}, Region: "af-south-1", EndpointServiceName: "com.amazonaws.vpce.af-south-1.vpce-svc-1"},
+		},
+		"unknown region": {
+			config:  awsutil.DataFederationEndpointConfig{VpcID: "vpc-1", SubnetIDs: []string{"subnet-1"}, Region: "af-south-1"},
+			wantErr: true,
+		},
+		"no subnet": {
+			config:  awsutil.DataFederationEndpointConfig{VpcID: "vpc-1", Region: "us-east-1"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		if err := tc.config.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}
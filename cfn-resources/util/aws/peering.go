@@ -0,0 +1,187 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	progress_events "github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+)
+
+const (
+	peeringStatusActive            = "active"
+	peeringStatusPendingAcceptance = "pending-acceptance"
+	peeringStatusInitiatingRequest = "initiating-request"
+	peeringStatusProvisioning      = "provisioning"
+
+	errCodeRouteTableNotFound = "InvalidRouteTableID.NotFound"
+	errCodeRouteNotFound      = "InvalidRoute.NotFound"
+)
+
+// VpcPeering manages, from the account of the peer VPC, the AWS side of a VPC peering connection requested by Atlas
+type VpcPeering struct {
+	svc          *ec2.EC2
+	ConnectionID string
+}
+
+func NewVpcPeering(req handler.Request, region, connectionID string) *VpcPeering {
+	return &VpcPeering{
+		svc:          newEc2Client(convertToAWSRegion(region), req),
+		ConnectionID: connectionID,
+	}
+}
+
+// Accept accepts the peering connection when it waits for acceptance. Connections that are already active,
+// or that AWS is still setting up, are left as they are.
+func (p *VpcPeering) Accept() *handler.ProgressEvent {
+	out, err := p.svc.DescribeVpcPeeringConnections(&ec2.DescribeVpcPeeringConnectionsInput{
+		VpcPeeringConnectionIds: []*string{aws.String(p.ConnectionID)},
+	})
+	if err != nil {
+		return failedEvent(fmt.Sprintf("Error describing VPC peering connection %s: %s", p.ConnectionID, err.Error()))
+	}
+	if len(out.VpcPeeringConnections) == 0 {
+		fpe := progress_events.GetFailedEventByCode(fmt.Sprintf("VPC peering connection %s not found", p.ConnectionID),
+			cloudformation.HandlerErrorCodeNotFound)
+		return &fpe
+	}
+
+	status := ""
+	if s := out.VpcPeeringConnections[0].Status; s != nil {
+		status = aws.StringValue(s.Code)
+	}
+	switch status {
+	case peeringStatusActive, peeringStatusInitiatingRequest, peeringStatusProvisioning:
+		return nil
+	case peeringStatusPendingAcceptance:
+		_, err = p.svc.AcceptVpcPeeringConnection(&ec2.AcceptVpcPeeringConnectionInput{
+			VpcPeeringConnectionId: aws.String(p.ConnectionID),
+		})
+		if err != nil {
+			return failedEvent(fmt.Sprintf("Error accepting VPC peering connection %s: %s", p.ConnectionID, err.Error()))
+		}
+		return nil
+	default:
+		return failedEvent(fmt.Sprintf("VPC peering connection %s can't be accepted, its status is %s", p.ConnectionID, status))
+	}
+}
+
+// SetDNSResolution sets whether the peer VPC resolves the hostnames of the Atlas clusters to their private IP
+// addresses. The connection must be active.
+func (p *VpcPeering) SetDNSResolution(enabled bool) *handler.ProgressEvent {
+	_, err := p.svc.ModifyVpcPeeringConnectionOptions(&ec2.ModifyVpcPeeringConnectionOptionsInput{
+		VpcPeeringConnectionId: aws.String(p.ConnectionID),
+		AccepterPeeringConnectionOptions: &ec2.PeeringConnectionOptionsRequest{
+			AllowDnsResolutionFromRemoteVpc: aws.Bool(enabled),
+		},
+	})
+	if err != nil {
+		return failedEvent(fmt.Sprintf("Error setting DNS resolution of VPC peering connection %s: %s", p.ConnectionID, err.Error()))
+	}
+	return nil
+}
+
+// AddRoutes routes the CIDR block through the peering connection in each route table. Route tables that already
+// have the route are skipped, a route table that sends the CIDR block somewhere else is an error.
+func (p *VpcPeering) AddRoutes(cidrBlock string, routeTableIDs []string) *handler.ProgressEvent {
+	for _, routeTableID := range routeTableIDs {
+		route, fpe := p.findRoute(routeTableID, cidrBlock)
+		if fpe != nil {
+			return fpe
+		}
+		if route != nil {
+			if aws.StringValue(route.VpcPeeringConnectionId) == p.ConnectionID {
+				continue
+			}
+			conflict := progress_events.GetFailedEventByCode(fmt.Sprintf("route table %s already has a route for %s that doesn't use VPC peering connection %s",
+				routeTableID, cidrBlock, p.ConnectionID), cloudformation.HandlerErrorCodeAlreadyExists)
+			return &conflict
+		}
+
+		_, err := p.svc.CreateRoute(&ec2.CreateRouteInput{
+			RouteTableId:           aws.String(routeTableID),
+			DestinationCidrBlock:   aws.String(cidrBlock),
+			VpcPeeringConnectionId: aws.String(p.ConnectionID),
+		})
+		if err != nil {
+			return failedEvent(fmt.Sprintf("Error creating route for %s in route table %s: %s", cidrBlock, routeTableID, err.Error()))
+		}
+	}
+	return nil
+}
+
+// DeleteRoutes removes the routes of the CIDR block through the peering connection from each route table. Routes
+// that use another target and route tables that no longer exist are left alone.
+func (p *VpcPeering) DeleteRoutes(cidrBlock string, routeTableIDs []string) *handler.ProgressEvent {
+	for _, routeTableID := range routeTableIDs {
+		route, fpe := p.findRoute(routeTableID, cidrBlock)
+		if fpe != nil {
+			if fpe.HandlerErrorCode == cloudformation.HandlerErrorCodeNotFound {
+				continue
+			}
+			return fpe
+		}
+		if route == nil || aws.StringValue(route.VpcPeeringConnectionId) != p.ConnectionID {
+			continue
+		}
+
+		_, err := p.svc.DeleteRoute(&ec2.DeleteRouteInput{
+			RouteTableId:         aws.String(routeTableID),
+			DestinationCidrBlock: aws.String(cidrBlock),
+		})
+		if err != nil && !isErrorCode(err, errCodeRouteNotFound) {
+			return failedEvent(fmt.Sprintf("Error deleting route for %s from route table %s: %s", cidrBlock, routeTableID, err.Error()))
+		}
+	}
+	return nil
+}
+
+// findRoute returns the route of the CIDR block in the route table, or nil if there is none
+func (p *VpcPeering) findRoute(routeTableID, cidrBlock string) (*ec2.Route, *handler.ProgressEvent) {
+	out, err := p.svc.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		RouteTableIds: []*string{aws.String(routeTableID)},
+	})
+	if err != nil || len(out.RouteTables) == 0 {
+		if err == nil || isErrorCode(err, errCodeRouteTableNotFound) {
+			fpe := progress_events.GetFailedEventByCode(fmt.Sprintf("route table %s not found", routeTableID),
+				cloudformation.HandlerErrorCodeNotFound)
+			return nil, &fpe
+		}
+		return nil, failedEvent(fmt.Sprintf("Error describing route table %s: %s", routeTableID, err.Error()))
+	}
+
+	for _, route := range out.RouteTables[0].Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == cidrBlock {
+			return route, nil
+		}
+	}
+	return nil, nil
+}
+
+func isErrorCode(err error, code string) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == code
+}
+
+func failedEvent(message string) *handler.ProgressEvent {
+	fpe := progress_events.GetFailedEventByCode(message, cloudformation.HandlerErrorCodeGeneralServiceException)
+	return &fpe
+}
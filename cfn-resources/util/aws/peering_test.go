@@ -0,0 +1,201 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	awsutil "github.com/mongodb/mongodbatlas-cloudformation-resources/util/aws"
+)
+
+const (
+	connectionID = "pcx-0123456789abcdef0"
+	atlasCIDR    = "192.168.248.0/21"
+)
+
+// ec2Stub answers the EC2 query API calls made for a single VPC peering connection
+type ec2Stub struct {
+	status string
+	dns    string
+	routes map[string]map[string]string // route table ID -> destination CIDR block -> target
+	calls  []string
+}
+
+func (s *ec2Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	action := r.Form.Get("Action")
+	s.calls = append(s.calls, action)
+
+	switch action {
+	case "DescribeVpcPeeringConnections":
+		writeEC2Response(w, action, fmt.Sprintf(
+			"<vpcPeeringConnectionSet><item><vpcPeeringConnectionId>%s</vpcPeeringConnectionId><status><code>%s</code></status></item></vpcPeeringConnectionSet>",
+			connectionID, s.status))
+	case "AcceptVpcPeeringConnection":
+		s.status = "provisioning"
+		writeEC2Response(w, action, "")
+	case "ModifyVpcPeeringConnectionOptions":
+		s.dns = r.Form.Get("AccepterPeeringConnectionOptions.AllowDnsResolutionFromRemoteVpc")
+		writeEC2Response(w, action, "")
+	case "DescribeRouteTables":
+		routeTableID := r.Form.Get("RouteTableId.1")
+		routes, ok := s.routes[routeTableID]
+		if !ok {
+			writeEC2Error(w, "InvalidRouteTableID.NotFound")
+			return
+		}
+		var routeSet strings.Builder
+		for cidr, target := range routes {
+			targetElement := "gatewayId"
+			if strings.HasPrefix(target, "pcx-") {
+				targetElement = "vpcPeeringConnectionId"
+			}
+			_, _ = fmt.Fprintf(&routeSet, "<item><destinationCidrBlock>%s</destinationCidrBlock><%s>%s</%s></item>", cidr, targetElement, target, targetElement)
+		}
+		writeEC2Response(w, action, fmt.Sprintf("<routeTableSet><item><routeTableId>%s</routeTableId><routeSet>%s</routeSet></item></routeTableSet>",
+			routeTableID, routeSet.String()))
+	case "CreateRoute":
+		s.routes[r.Form.Get("RouteTableId")][r.Form.Get("DestinationCidrBlock")] = r.Form.Get("VpcPeeringConnectionId")
+		writeEC2Response(w, action, "<return>true</return>")
+	case "DeleteRoute":
+		delete(s.routes[r.Form.Get("RouteTableId")], r.Form.Get("DestinationCidrBlock"))
+		writeEC2Response(w, action, "")
+	default:
+		writeEC2Error(w, "InvalidAction")
+	}
+}
+
+func writeEC2Response(w http.ResponseWriter, action, body string) {
+	_, _ = fmt.Fprintf(w, `<%sResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>1</requestId>%s</%sResponse>`, action, body, action)
+}
+
+func writeEC2Error(w http.ResponseWriter, code string) {
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = fmt.Fprintf(w, `<Response><Errors><Error><Code>%s</Code><Message>stub error</Message></Error></Errors><RequestID>1</RequestID></Response>`, code)
+}
+
+func newVpcPeering(t *testing.T, stub *ec2Stub) *awsutil.VpcPeering {
+	t.Helper()
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	return awsutil.NewVpcPeering(handler.Request{Session: sess}, "US_EAST_1", connectionID)
+}
+
+func countCalls(calls []string, action string) int {
+	count := 0
+	for _, call := range calls {
+		if call == action {
+			count++
+		}
+	}
+	return count
+}
+
+func TestVpcPeeringAccept(t *testing.T) {
+	tests := []struct {
+		status       string
+		wantAccepted bool
+		wantError    bool
+	}{
+		{status: "pending-acceptance", wantAccepted: true},
+		{status: "active"},
+		{status: "provisioning"},
+		{status: "rejected", wantError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			stub := &ec2Stub{status: test.status}
+			fpe := newVpcPeering(t, stub).Accept()
+			if (fpe != nil) != test.wantError {
+				t.Fatalf("Accept() = %v, want error %t", fpe, test.wantError)
+			}
+			if accepted := countCalls(stub.calls, "AcceptVpcPeeringConnection") == 1; accepted != test.wantAccepted {
+				t.Errorf("calls = %v, want accepted %t", stub.calls, test.wantAccepted)
+			}
+		})
+	}
+}
+
+func TestVpcPeeringSetDNSResolution(t *testing.T) {
+	stub := &ec2Stub{status: "active"}
+	if fpe := newVpcPeering(t, stub).SetDNSResolution(true); fpe != nil {
+		t.Fatalf("SetDNSResolution() = %v", fpe)
+	}
+	if stub.dns != "true" {
+		t.Errorf("AllowDnsResolutionFromRemoteVpc = %q, want true", stub.dns)
+	}
+}
+
+func TestVpcPeeringAddRoutes(t *testing.T) {
+	stub := &ec2Stub{routes: map[string]map[string]string{
+		"rtb-new":      {"10.0.0.0/16": "local"},
+		"rtb-existing": {atlasCIDR: connectionID},
+	}}
+	peering := newVpcPeering(t, stub)
+
+	if fpe := peering.AddRoutes(atlasCIDR, []string{"rtb-new", "rtb-existing"}); fpe != nil {
+		t.Fatalf("AddRoutes() = %v", fpe)
+	}
+	if got := stub.routes["rtb-new"][atlasCIDR]; got != connectionID {
+		t.Errorf("route of rtb-new targets %q, want %s", got, connectionID)
+	}
+	if created := countCalls(stub.calls, "CreateRoute"); created != 1 {
+		t.Errorf("CreateRoute called %d times, want 1 for the route table without the route", created)
+	}
+
+	stub.routes["rtb-conflict"] = map[string]string{atlasCIDR: "igw-1"}
+	fpe := peering.AddRoutes(atlasCIDR, []string{"rtb-conflict"})
+	if fpe == nil || fpe.HandlerErrorCode != cloudformation.HandlerErrorCodeAlreadyExists {
+		t.Errorf("AddRoutes() on a conflicting route = %v, want AlreadyExists", fpe)
+	}
+
+	fpe = peering.AddRoutes(atlasCIDR, []string{"rtb-missing"})
+	if fpe == nil || fpe.HandlerErrorCode != cloudformation.HandlerErrorCodeNotFound {
+		t.Errorf("AddRoutes() on a missing route table = %v, want NotFound", fpe)
+	}
+}
+
+func TestVpcPeeringDeleteRoutes(t *testing.T) {
+	stub := &ec2Stub{routes: map[string]map[string]string{
+		"rtb-peered": {atlasCIDR: connectionID},
+		"rtb-other":  {atlasCIDR: "igw-1"},
+	}}
+
+	if fpe := newVpcPeering(t, stub).DeleteRoutes(atlasCIDR, []string{"rtb-peered", "rtb-other", "rtb-missing"}); fpe != nil {
+		t.Fatalf("DeleteRoutes() = %v", fpe)
+	}
+	if _, ok := stub.routes["rtb-peered"][atlasCIDR]; ok {
+		t.Error("route of rtb-peered wasn't deleted")
+	}
+	if got := stub.routes["rtb-other"][atlasCIDR]; got != "igw-1" {
+		t.Errorf("route of rtb-other targets %q, want it left on igw-1", got)
+	}
+}
@@ -15,6 +15,8 @@
 package aws
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
@@ -29,10 +31,21 @@ func newEc2Client(region string, req handler.Request) *ec2.EC2 {
 	return ec2.New(req.Session, aws.NewConfig().WithRegion(region))
 }
 
+// PrivateEndpointInput is the VPC of an interface endpoint. A ClientToken makes the creation idempotent: a retry
+// with the same token returns the interface endpoint created by the first attempt.
 type PrivateEndpointInput struct {
 	VpcID               string
 	SubnetIDs           []string
 	InterfaceEndpointID *string
+	ClientToken         string
+}
+
+// NewClientToken returns a random client token, to save in the callback context before the first attempt of a
+// creation so that its retries reuse it
+func NewClientToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 type PrivateEndpointOutput struct {
@@ -66,6 +79,9 @@ func CreatePrivateEndpoint(req handler.Request, endpointServiceName string, regi
 			VpcEndpointType: &vcpType,
 			SubnetIds:       subnetIdsIn,
 		}
+		if pe.ClientToken != "" {
+			connection.ClientToken = aws.String(pe.ClientToken)
+		}
 
 		vpcE, err := svc.CreateVpcEndpoint(&connection)
 		if err != nil {
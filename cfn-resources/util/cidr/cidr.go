@@ -0,0 +1,167 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cidr validates and allocates the IPv4 CIDR blocks of Atlas network containers and of the VPCs
+// peered with them.
+package cidr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+const (
+	// MinPrefixLength and MaxPrefixLength bound the size of an Atlas CIDR block on AWS, from /21 to /24
+	MinPrefixLength = 21
+	MaxPrefixLength = 24
+)
+
+var (
+	// privateRanges are the RFC 1918 ranges, Atlas CIDR blocks must fall within one of them
+	privateRanges = mustParseAll("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16")
+
+	// reservedRanges can't hold an Atlas CIDR block, AWS services use 172.17.0.0/16 inside VPCs
+	reservedRanges = mustParseAll("172.17.0.0/16")
+)
+
+// Parse parses an IPv4 CIDR block given by its network address, such as 10.0.0.0/24 but not 10.0.0.1/24
+func Parse(block string) (*net.IPNet, error) {
+	ip, ipNet, err := net.ParseCIDR(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a CIDR block", block)
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 CIDR block", block)
+	}
+	if !ip.Equal(ipNet.IP) {
+		return nil, fmt.Errorf("%s is not the network address of its CIDR block, use %s", block, ipNet.String())
+	}
+	return ipNet, nil
+}
+
+// Overlaps reports whether the two CIDR blocks share addresses
+func Overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// ValidateAtlasBlock checks that the block can be the CIDR block of an Atlas network container: a private
+// IPv4 block from /21 to /24, outside of the reserved ranges
+func ValidateAtlasBlock(block string) error {
+	ipNet, err := Parse(block)
+	if err != nil {
+		return err
+	}
+	if ones, _ := ipNet.Mask.Size(); ones < MinPrefixLength || ones > MaxPrefixLength {
+		return fmt.Errorf("the Atlas CIDR block %s must be from /%d to /%d", block, MinPrefixLength, MaxPrefixLength)
+	}
+	if !withinAny(ipNet, privateRanges) {
+		return fmt.Errorf("the Atlas CIDR block %s must be within the RFC 1918 ranges 10.0.0.0/8, 172.16.0.0/12 or 192.168.0.0/16", block)
+	}
+	for _, reserved := range reservedRanges {
+		if Overlaps(ipNet, reserved) {
+			return fmt.Errorf("the Atlas CIDR block %s overlaps the reserved range %s", block, reserved.String())
+		}
+	}
+	return nil
+}
+
+// CheckOverlap returns an error naming the first of the other blocks that overlaps the block. Blocks that
+// can't be parsed are ignored, they are validated where they are set.
+func CheckOverlap(block string, others []string) error {
+	ipNet, err := Parse(block)
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		otherNet, parseErr := Parse(other)
+		if parseErr != nil {
+			continue
+		}
+		if Overlaps(ipNet, otherNet) {
+			return fmt.Errorf("the CIDR block %s overlaps %s", block, other)
+		}
+	}
+	return nil
+}
+
+// NextFree returns the first valid Atlas CIDR block of the prefix length within the supernet that overlaps
+// none of the used blocks
+func NextFree(supernet string, prefixLength int, used []string) (string, error) {
+	superNet, err := Parse(supernet)
+	if err != nil {
+		return "", err
+	}
+	if prefixLength < MinPrefixLength || prefixLength > MaxPrefixLength {
+		return "", fmt.Errorf("the prefix length of an Atlas CIDR block must be from %d to %d, not %d", MinPrefixLength, MaxPrefixLength, prefixLength)
+	}
+	superOnes, _ := superNet.Mask.Size()
+	if superOnes > prefixLength {
+		return "", fmt.Errorf("the supernet %s is smaller than a /%d block", supernet, prefixLength)
+	}
+
+	var usedNets []*net.IPNet
+	for _, block := range used {
+		if ipNet, parseErr := Parse(block); parseErr == nil {
+			usedNets = append(usedNets, ipNet)
+		}
+	}
+
+	first := binary.BigEndian.Uint32(superNet.IP.To4())
+	size := uint64(1) << (32 - prefixLength)
+	count := uint64(1) << (prefixLength - superOnes)
+	mask := net.CIDRMask(prefixLength, 32)
+	for i := uint64(0); i < count; i++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, first+uint32(i*size))
+		candidate := &net.IPNet{IP: ip, Mask: mask}
+		if ValidateAtlasBlock(candidate.String()) != nil || overlapsAny(candidate, usedNets) {
+			continue
+		}
+		return candidate.String(), nil
+	}
+	return "", fmt.Errorf("the supernet %s has no free /%d block", supernet, prefixLength)
+}
+
+func withinAny(ipNet *net.IPNet, ranges []*net.IPNet) bool {
+	ones, _ := ipNet.Mask.Size()
+	for _, r := range ranges {
+		rangeOnes, _ := r.Mask.Size()
+		if rangeOnes <= ones && r.Contains(ipNet.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapsAny(ipNet *net.IPNet, others []*net.IPNet) bool {
+	for _, other := range others {
+		if Overlaps(ipNet, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseAll(blocks ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(blocks))
+	for i, block := range blocks {
+		_, ipNet, err := net.ParseCIDR(block)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipNet
+	}
+	return nets
+}
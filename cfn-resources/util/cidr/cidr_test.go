@@ -0,0 +1,88 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cidr_test
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/cidr"
+)
+
+func TestValidateAtlasBlock(t *testing.T) {
+	tests := []struct {
+		block   string
+		wantErr bool
+	}{
+		{"192.168.248.0/21", false},
+		{"10.8.0.0/24", false},
+		{"172.16.0.0/22", false},
+		{"10.8.0.1/24", true},
+		{"10.8.0.0/20", true},
+		{"10.8.0.0/25", true},
+		{"100.64.0.0/24", true},
+		{"172.17.4.0/24", true},
+		{"fd00::/120", true},
+		{"not-a-block", true},
+	}
+	for _, test := range tests {
+		if err := cidr.ValidateAtlasBlock(test.block); (err != nil) != test.wantErr {
+			t.Errorf("ValidateAtlasBlock(%s) = %v, want error %t", test.block, err, test.wantErr)
+		}
+	}
+}
+
+func TestCheckOverlap(t *testing.T) {
+	tests := []struct {
+		block   string
+		others  []string
+		wantErr bool
+	}{
+		{"10.0.0.0/24", []string{"10.0.1.0/24", "192.168.0.0/16"}, false},
+		{"10.0.0.0/21", []string{"10.0.4.0/24"}, true},
+		{"10.0.4.0/24", []string{"10.0.0.0/16"}, true},
+		{"10.0.0.0/24", []string{"invalid", "10.0.0.0/24"}, true},
+		{"10.0.0.0/24", nil, false},
+	}
+	for _, test := range tests {
+		if err := cidr.CheckOverlap(test.block, test.others); (err != nil) != test.wantErr {
+			t.Errorf("CheckOverlap(%s, %v) = %v, want error %t", test.block, test.others, err, test.wantErr)
+		}
+	}
+}
+
+func TestNextFree(t *testing.T) {
+	tests := []struct {
+		supernet     string
+		prefixLength int
+		used         []string
+		want         string
+		wantErr      bool
+	}{
+		{supernet: "10.0.0.0/16", prefixLength: 21, want: "10.0.0.0/21"},
+		{supernet: "10.0.0.0/16", prefixLength: 24, used: []string{"10.0.0.0/24", "10.0.1.0/25"}, want: "10.0.2.0/24"},
+		{supernet: "10.0.0.0/16", prefixLength: 21, used: []string{"10.0.3.0/24"}, want: "10.0.8.0/21"},
+		{supernet: "172.16.0.0/12", prefixLength: 24, used: []string{"172.16.0.0/16"}, want: "172.18.0.0/24"},
+		{supernet: "10.0.0.0/23", prefixLength: 24, used: []string{"10.0.0.0/23"}, wantErr: true},
+		{supernet: "10.0.0.0/24", prefixLength: 21, wantErr: true},
+		{supernet: "10.0.0.0/16", prefixLength: 20, wantErr: true},
+		{supernet: "10.0.0.1/16", prefixLength: 24, wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := cidr.NextFree(test.supernet, test.prefixLength, test.used)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("NextFree(%s, %d, %v) = %q, %v, want %q, error %t", test.supernet, test.prefixLength, test.used, got, err, test.want, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import atlasSDK "go.mongodb.org/atlas-sdk/v20231001001/admin"
+
+// ClusterRegions returns the regions of the cluster as PROVIDER/REGION keys. When providerName is set, only the
+// regions of that provider are returned.
+func ClusterRegions(cluster *atlasSDK.AdvancedClusterDescription, providerName string) map[string]bool {
+	regions := make(map[string]bool)
+	for i := range cluster.ReplicationSpecs {
+		for j := range cluster.ReplicationSpecs[i].RegionConfigs {
+			regionConfig := &cluster.ReplicationSpecs[i].RegionConfigs[j]
+			provider := SafeString(regionConfig.ProviderName)
+			if providerName != "" && provider != providerName {
+				continue
+			}
+			regions[provider+"/"+SafeString(regionConfig.RegionName)] = true
+		}
+	}
+	return regions
+}
@@ -132,6 +132,7 @@ const (
 	RealmPvtKey = "RealmConfig.PrivateKey"
 
 	FederationSettingsID = "FederationSettingsId"
+	Protocol             = "Protocol"
 
 	ExportBucketID             = "ExportBucketId"
 	ExportID                   = "ExportId"
@@ -0,0 +1,71 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package federation holds what the federated authentication resources share: calls to the federation
+// settings endpoints and paging through their lists.
+package federation
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+)
+
+const (
+	// APIVersion is the Admin API resource version that covers OIDC identity providers and paginated lists
+	APIVersion = "2023-11-15"
+
+	ItemsPerPage = 100
+)
+
+// page is the envelope of the paginated federation list endpoints
+type page[T any] struct {
+	Results []T `json:"results"`
+}
+
+// SettingsPath returns the path of the federation settings, followed by the given path elements
+func SettingsPath(federationSettingsID string, elements ...string) string {
+	path := "/api/atlas/v2/federationSettings/" + url.PathEscape(federationSettingsID)
+	for _, element := range elements {
+		path += "/" + url.PathEscape(element)
+	}
+	return path
+}
+
+// Call sends a request to a federation settings endpoint
+func Call(client *util.MongoDBClient, method, path string, body, result any) (*http.Response, error) {
+	return util.CallAtlasV2APIWithVersion(client, APIVersion, method, path, body, result)
+}
+
+// ListAll pages through a federation list endpoint and returns all its results
+func ListAll[T any](client *util.MongoDBClient, path string, query url.Values) ([]T, *http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("itemsPerPage", strconv.Itoa(ItemsPerPage))
+
+	return util.ListAll(ItemsPerPage, func(pageNum int) ([]T, *http.Response, error) {
+		query.Set("pageNum", strconv.Itoa(pageNum))
+		result := new(page[T])
+		res, err := Call(client, http.MethodGet, path+"?"+query.Encode(), nil, result)
+		return result.Results, res, err
+	})
+}
+
+// IsNotFound reports whether the response is a 404
+func IsNotFound(res *http.Response) bool {
+	return res != nil && res.StatusCode == http.StatusNotFound
+}
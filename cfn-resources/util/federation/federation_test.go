@@ -0,0 +1,77 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/federation"
+)
+
+func TestSettingsPath(t *testing.T) {
+	got := federation.SettingsPath("abc", "identityProviders", "a/b")
+	if want := "/api/atlas/v2/federationSettings/abc/identityProviders/a%2Fb"; got != want {
+		t.Errorf("SettingsPath() = %s; want %s", got, want)
+	}
+}
+
+func TestListAll(t *testing.T) {
+	total := federation.ItemsPerPage + 5
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.atlas.2023-11-15+json" {
+			t.Errorf("unexpected Accept header %s", accept)
+		}
+		pageNum, _ := strconv.Atoi(r.URL.Query().Get("pageNum"))
+		first := (pageNum - 1) * federation.ItemsPerPage
+		last := first + federation.ItemsPerPage
+		if last > total {
+			last = total
+		}
+		results := "["
+		for i := first; i < last; i++ {
+			if i > first {
+				results += ","
+			}
+			results += fmt.Sprintf(`{"id":"%d"}`, i)
+		}
+		results += "]"
+		_, _ = fmt.Fprintf(w, `{"results":%s,"totalCount":%d}`, results, total)
+	}))
+	defer server.Close()
+
+	client, err := util.NewAtlasV2ClientWithKeys("public", "private", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type item struct {
+		ID string `json:"id"`
+	}
+	items, _, err := federation.ListAll[item](client, federation.SettingsPath("abc", "identityProviders"), nil)
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if len(items) != total {
+		t.Fatalf("ListAll() returned %d items; want %d", len(items), total)
+	}
+	if items[total-1].ID != strconv.Itoa(total-1) {
+		t.Errorf("last item = %s; want %d", items[total-1].ID, total-1)
+	}
+}
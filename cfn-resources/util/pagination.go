@@ -0,0 +1,33 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import "net/http"
+
+// ListAll calls listPage with the page numbers from 1 on, until a page has fewer than itemsPerPage results, and
+// returns the results of all the pages with the last response
+func ListAll[T any](itemsPerPage int, listPage func(pageNum int) ([]T, *http.Response, error)) ([]T, *http.Response, error) {
+	var all []T
+	for pageNum := 1; ; pageNum++ {
+		results, res, err := listPage(pageNum)
+		if err != nil {
+			return nil, res, err
+		}
+		all = append(all, results...)
+		if len(results) < itemsPerPage {
+			return all, res, nil
+		}
+	}
+}
@@ -22,6 +22,9 @@ import (
 )
 
 func getHandlerErrorCode(response *http.Response) string {
+	if response == nil {
+		return cloudformation.HandlerErrorCodeInternalFailure
+	}
 	switch response.StatusCode {
 	case http.StatusBadRequest:
 		return cloudformation.HandlerErrorCodeInvalidRequest
@@ -36,6 +39,8 @@ func getHandlerErrorCode(response *http.Response) string {
 	}
 }
 
+// GetFailedEventByResponse returns the failed event with the handler error code of the status of the response. A
+// call that failed before a response was received, with a nil response, is an internal failure.
 func GetFailedEventByResponse(message string, response *http.Response) handler.ProgressEvent {
 	return handler.ProgressEvent{
 		OperationStatus:  handler.Failed,
@@ -0,0 +1,39 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progressevent_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+)
+
+func TestGetFailedEventByResponse(t *testing.T) {
+	tests := []struct {
+		response *http.Response
+		wantCode string
+	}{
+		{&http.Response{StatusCode: http.StatusNotFound}, cloudformation.HandlerErrorCodeNotFound},
+		{&http.Response{StatusCode: http.StatusConflict}, cloudformation.HandlerErrorCodeInternalFailure},
+		{nil, cloudformation.HandlerErrorCodeInternalFailure},
+	}
+	for _, tt := range tests {
+		if pe := progressevent.GetFailedEventByResponse("error", tt.response); pe.HandlerErrorCode != tt.wantCode {
+			t.Errorf("%v: got %s, want %s", tt.response, pe.HandlerErrorCode, tt.wantCode)
+		}
+	}
+}
@@ -95,3 +95,95 @@ func Delete(req *handler.Request, secretName string) (err error) {
 	}
 	return nil
 }
+
+// GetRandomPassword asks Secrets Manager for a random password of the given length. Punctuation is
+// excluded so that the password can be embedded in a MongoDB connection string without escaping.
+func GetRandomPassword(req *handler.Request, length int64) (*string, error) {
+	sm := secretsmanager.New(req.Session)
+	output, err := sm.GetRandomPassword(&secretsmanager.GetRandomPasswordInput{
+		PasswordLength:          aws.Int64(length),
+		ExcludePunctuation:      aws.Bool(true),
+		RequireEachIncludedType: aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("error generating random password: %v", err.Error())
+		return nil, err
+	}
+	return output.RandomPassword, nil
+}
+
+const (
+	StageCurrent  = "AWSCURRENT"
+	StagePending  = "AWSPENDING"
+	StagePrevious = "AWSPREVIOUS"
+)
+
+// PutPendingSecret stores data as a new version of the secret labelled AWSPENDING only, so that readers of
+// AWSCURRENT keep the previous value until PromoteSecretVersion is called. The version id is the
+// clientRequestToken when provided, otherwise one is generated.
+func PutPendingSecret(req *handler.Request, secretName string, data interface{}, clientRequestToken *string) (versionID *string, err error) {
+	secretString, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := secretsmanager.New(req.Session)
+	input := &secretsmanager.PutSecretValueInput{
+		SecretId:           aws.String(secretName),
+		SecretString:       aws.String(string(secretString)),
+		ClientRequestToken: clientRequestToken,
+		VersionStages:      []*string{aws.String(StagePending)},
+	}
+
+	result, err := svc.PutSecretValue(input)
+	if err != nil {
+		log.Printf("error during put pending secret: %+v", err.Error())
+		return nil, err
+	}
+	return result.VersionId, nil
+}
+
+// GetSecretVersion returns the value of the secret version identified by versionID and/or stage
+func GetSecretVersion(req *handler.Request, secretName string, versionID, stage *string) (secretString *string, version *string, err error) {
+	sm := secretsmanager.New(req.Session)
+	output, err := sm.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: &secretName, VersionId: versionID, VersionStage: stage})
+	if err != nil {
+		return nil, nil, err
+	}
+	return output.SecretString, output.VersionId, nil
+}
+
+// PromoteSecretVersion moves the AWSCURRENT label to versionID. Secrets Manager then labels the version
+// that was current as AWSPREVIOUS, so it stays available until the next rotation.
+func PromoteSecretVersion(req *handler.Request, secretName, versionID string) error {
+	sm := secretsmanager.New(req.Session)
+	description, err := sm.DescribeSecret(&secretsmanager.DescribeSecretInput{SecretId: &secretName})
+	if err != nil {
+		log.Printf("error describing secret: %v", err.Error())
+		return err
+	}
+
+	var currentVersionID *string
+	for id, stages := range description.VersionIdsToStages {
+		for _, stage := range stages {
+			if aws.StringValue(stage) == StageCurrent {
+				currentVersionID = aws.String(id)
+			}
+		}
+	}
+	if aws.StringValue(currentVersionID) == versionID {
+		return nil
+	}
+
+	_, err = sm.UpdateSecretVersionStage(&secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            &secretName,
+		VersionStage:        aws.String(StageCurrent),
+		MoveToVersionId:     &versionID,
+		RemoveFromVersionId: currentVersionID,
+	})
+	if err != nil {
+		log.Printf("error promoting secret version: %v", err.Error())
+		return err
+	}
+	return nil
+}
@@ -270,6 +270,25 @@ func NewAtlasV2OnlyClient(req *handler.Request, profileName *string, profileName
 	return clients, nil
 }
 
+// NewAtlasV2ClientWithKeys func for creating an atlas-go-sdk client authenticated with the given key pair instead of a profile
+func NewAtlasV2ClientWithKeys(publicKey, privateKey, baseURL string) (*MongoDBClient, error) {
+	client, err := digest.NewTransport(publicKey, privateKey).Client()
+	if err != nil {
+		return nil, err
+	}
+
+	c := Config{BaseURL: baseURL}
+	sdkV2Client, err := c.newSDKV2Client(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoDBClient{
+		AtlasV2: sdkV2Client,
+		Config:  &c,
+	}, nil
+}
+
 func (c *Config) newSDKV2Client(client *http.Client) (*atlasSDK.APIClient, error) {
 	opts := []atlasSDK.ClientModifier{
 		atlasSDK.UseHTTPClient(client),
@@ -15,9 +15,12 @@
 package util_test
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/mongodb/mongodbatlas-cloudformation-resources/util"
+	"go.mongodb.org/atlas-sdk/v20231001001/admin"
 )
 
 var (
@@ -64,3 +67,46 @@ func TestAreStringPtrEqual(t *testing.T) {
 		}
 	}
 }
+
+func TestListAll(t *testing.T) {
+	const itemsPerPage, total = 2, 5
+	var pages []int
+	items, _, err := util.ListAll(itemsPerPage, func(pageNum int) ([]int, *http.Response, error) {
+		pages = append(pages, pageNum)
+		var results []int
+		for i := (pageNum - 1) * itemsPerPage; i < pageNum*itemsPerPage && i < total; i++ {
+			results = append(results, i)
+		}
+		return results, nil, nil
+	})
+	if err != nil || len(items) != total || items[total-1] != total-1 {
+		t.Fatalf("ListAll() = %v, %v", items, err)
+	}
+	if len(pages) != 3 {
+		t.Errorf("ListAll() requested pages %v; want 1 to 3", pages)
+	}
+
+	if _, _, err = util.ListAll(itemsPerPage, func(int) ([]int, *http.Response, error) {
+		return nil, nil, errors.New("failed")
+	}); err == nil {
+		t.Error("ListAll() should return the error of the page")
+	}
+}
+
+func TestClusterRegions(t *testing.T) {
+	regionConfig := func(provider, region string) admin.CloudRegionConfig {
+		return admin.CloudRegionConfig{ProviderName: &provider, RegionName: &region}
+	}
+	cluster := &admin.AdvancedClusterDescription{
+		ReplicationSpecs: []admin.ReplicationSpec{
+			{RegionConfigs: []admin.CloudRegionConfig{regionConfig("AWS", "US_EAST_1"), regionConfig("GCP", "CENTRAL_US")}},
+			{RegionConfigs: []admin.CloudRegionConfig{regionConfig("AWS", "US_EAST_1"), regionConfig("AWS", "EU_WEST_1")}},
+		},
+	}
+	if regions := util.ClusterRegions(cluster, ""); len(regions) != 3 || !regions["GCP/CENTRAL_US"] {
+		t.Errorf("ClusterRegions() = %v", regions)
+	}
+	if regions := util.ClusterRegions(cluster, "AWS"); len(regions) != 2 || !regions["AWS/EU_WEST_1"] {
+		t.Errorf("ClusterRegions(AWS) = %v", regions)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workflow runs the handlers that take several steps and callbacks to complete, like creating an Atlas
+// object and then the AWS objects that connect to it. The state of the steps is saved in the callback context
+// between the callbacks.
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/logger"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/progressevent"
+)
+
+// Defaults of the attempts of a step and of the delay between the callbacks, when the workflow doesn't set them
+const (
+	DefaultMaxAttempts          = 3
+	DefaultCallbackDelaySeconds = 20
+
+	stepKey = "Step"
+)
+
+// Step is one step of a workflow. Run is called on every callback until it reports the step done, it returns an
+// error to retry the step. Rollback undoes the step once the workflow fails, it must accept the state of a step
+// that was only partially run. When Atlas or AWS undo the step asynchronously, WaitRollback is called on every
+// callback after Rollback until it reports the step undone, the previous steps are only rolled back then.
+type Step[S any] struct {
+	Name         string
+	Run          func(state *S) (done bool, err error)
+	Rollback     func(state *S) error
+	WaitRollback func(state *S) (done bool, err error)
+}
+
+// Workflow runs its steps in order. The state S is shared by the steps and saved in the callback context, so it
+// must marshal to JSON.
+type Workflow[S any] struct {
+	Steps                []Step[S]
+	MaxAttempts          int
+	CallbackDelaySeconds int64
+}
+
+// Failure is an error that retrying the step can't fix, the workflow fails at once with its handler error code
+type Failure struct {
+	Code string
+	Err  error
+}
+
+// Error returns the message of the wrapped error
+func (f *Failure) Error() string {
+	return f.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// Fail returns a Failure with the handler error code
+func Fail(code string, err error) error {
+	return &Failure{Code: code, Err: err}
+}
+
+// EventError returns the error of a failed progress event. The events of invalid requests, missing objects and
+// access errors are Failures, the others are retried.
+func EventError(pe *handler.ProgressEvent) error {
+	err := errors.New(pe.Message)
+	switch pe.HandlerErrorCode {
+	case cloudformation.HandlerErrorCodeInvalidRequest,
+		cloudformation.HandlerErrorCodeNotFound,
+		cloudformation.HandlerErrorCodeAlreadyExists,
+		cloudformation.HandlerErrorCodeAccessDenied,
+		cloudformation.HandlerErrorCodeInvalidCredentials,
+		cloudformation.HandlerErrorCodeNotUpdatable:
+		return Fail(pe.HandlerErrorCode, err)
+	default:
+		return err
+	}
+}
+
+// checkpoint is what the workflow saves in the callback context: the step to run, the failed attempts of the
+// step and the state of the steps. Once a step failed, Failure is set and Step is the step to roll back.
+type checkpoint[S any] struct {
+	Step            string
+	Attempts        int
+	LastError       string   `json:",omitempty"`
+	Failure         string   `json:",omitempty"`
+	FailureCode     string   `json:",omitempty"`
+	RollbackStarted bool     `json:",omitempty"`
+	RollbackErrors  []string `json:",omitempty"`
+	State           S
+}
+
+// Run runs the steps from the one saved in the callback context, and goes on with the next steps as long as they
+// complete. It returns nil once the last step is done, otherwise the event of the handler: in progress, with the
+// model and the callback context, until a step needs another callback or is being rolled back, or failed, once the
+// steps were rolled back.
+func (w *Workflow[S]) Run(callbackContext map[string]interface{}, model interface{}) *handler.ProgressEvent {
+	cp, err := load[S](callbackContext)
+	if err != nil {
+		pe := progressevent.GetFailedEventByCode(fmt.Sprintf("Error parsing callback context : %s", err.Error()),
+			cloudformation.HandlerErrorCodeServiceInternalError)
+		return &pe
+	}
+	i, err := w.stepIndex(cp.Step)
+	if err != nil {
+		pe := progressevent.GetFailedEventByCode(err.Error(), cloudformation.HandlerErrorCodeServiceInternalError)
+		return &pe
+	}
+	if cp.Failure != "" {
+		return w.rollback(cp, i, model)
+	}
+
+	for ; i < len(w.Steps); i++ {
+		step := &w.Steps[i]
+		if cp.Step != step.Name {
+			cp.Step = step.Name
+			cp.Attempts = 0
+			cp.LastError = ""
+		}
+
+		done, runErr := step.Run(&cp.State)
+		if runErr != nil {
+			cp.Attempts++
+			cp.LastError = runErr.Error()
+			_, _ = logger.Warnf("workflow step %s, attempt %d: %v", step.Name, cp.Attempts, runErr)
+
+			var failure *Failure
+			if errors.As(runErr, &failure) || cp.Attempts >= w.maxAttempts() {
+				cp.Failure = fmt.Sprintf("Error in step %s after %d attempts: %s", step.Name, cp.Attempts, runErr.Error())
+				cp.FailureCode = cloudformation.HandlerErrorCodeGeneralServiceException
+				if failure != nil {
+					cp.FailureCode = failure.Code
+				}
+				cp.Attempts = 0
+				cp.LastError = ""
+				return w.rollback(cp, i, model)
+			}
+			return w.inProgress(cp, model, fmt.Sprintf("Retrying %s", step.Name))
+		}
+
+		cp.Attempts = 0
+		cp.LastError = ""
+		if !done {
+			return w.inProgress(cp, model, fmt.Sprintf("Running %s", step.Name))
+		}
+	}
+	return nil
+}
+
+// rollback undoes the steps from the one in the checkpoint back to the first one, and returns the failed event once
+// they are undone. A failed rollback doesn't stop the rollback of the previous steps, it is reported in the event so
+// that the objects that are left can be removed by hand.
+func (w *Workflow[S]) rollback(cp *checkpoint[S], from int, model interface{}) *handler.ProgressEvent {
+	for i := from; i >= 0; i-- {
+		step := &w.Steps[i]
+		if cp.Step != step.Name {
+			cp.Step = step.Name
+			cp.Attempts = 0
+			cp.RollbackStarted = false
+		}
+
+		if !cp.RollbackStarted {
+			cp.RollbackStarted = true
+			if step.Rollback != nil {
+				if err := step.Rollback(&cp.State); err != nil {
+					w.rollbackFailed(cp, step.Name, err)
+					continue
+				}
+			}
+		}
+		if step.WaitRollback == nil {
+			continue
+		}
+
+		done, err := step.WaitRollback(&cp.State)
+		if err != nil {
+			cp.Attempts++
+			if cp.Attempts < w.maxAttempts() {
+				_, _ = logger.Warnf("workflow step %s, rollback attempt %d: %v", step.Name, cp.Attempts, err)
+				return w.inProgress(cp, model, fmt.Sprintf("Rolling back %s", step.Name))
+			}
+			w.rollbackFailed(cp, step.Name, err)
+			continue
+		}
+		if !done {
+			return w.inProgress(cp, model, fmt.Sprintf("Rolling back %s", step.Name))
+		}
+	}
+
+	message := cp.Failure
+	if len(cp.RollbackErrors) > 0 {
+		message = fmt.Sprintf("%s. Rollback failed, %s", message, strings.Join(cp.RollbackErrors, ", "))
+	}
+	pe := progressevent.GetFailedEventByCode(message, cp.FailureCode)
+	return &pe
+}
+
+func (w *Workflow[S]) rollbackFailed(cp *checkpoint[S], stepName string, err error) {
+	_, _ = logger.Warnf("workflow step %s, rollback: %v", stepName, err)
+	cp.RollbackErrors = append(cp.RollbackErrors, fmt.Sprintf("%s: %s", stepName, err.Error()))
+}
+
+func (w *Workflow[S]) inProgress(cp *checkpoint[S], model interface{}, message string) *handler.ProgressEvent {
+	callbackContext, err := save(cp)
+	if err != nil {
+		pe := progressevent.GetFailedEventByCode(fmt.Sprintf("Error saving callback context : %s", err.Error()),
+			cloudformation.HandlerErrorCodeServiceInternalError)
+		return &pe
+	}
+	delay := w.CallbackDelaySeconds
+	if delay == 0 {
+		delay = DefaultCallbackDelaySeconds
+	}
+	pe := progressevent.GetInProgressProgressEvent(message, callbackContext, model, delay)
+	return &pe
+}
+
+func (w *Workflow[S]) maxAttempts() int {
+	if w.MaxAttempts > 0 {
+		return w.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+func (w *Workflow[S]) stepIndex(name string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for i := range w.Steps {
+		if w.Steps[i].Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown workflow step %s", name)
+}
+
+// load reads the checkpoint saved in the callback context, an empty one starts the workflow
+func load[S any](callbackContext map[string]interface{}) (*checkpoint[S], error) {
+	cp := &checkpoint[S]{}
+	if _, ok := callbackContext[stepKey]; !ok {
+		return cp, nil
+	}
+	data, err := json.Marshal(callbackContext)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func save[S any](cp *checkpoint[S]) (map[string]interface{}, error) {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+	var callbackContext map[string]interface{}
+	err = json.Unmarshal(data, &callbackContext)
+	return callbackContext, err
+}
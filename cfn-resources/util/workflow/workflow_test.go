@@ -0,0 +1,194 @@
+// Copyright 2023 MongoDB Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws-cloudformation/cloudformation-cli-go-plugin/cfn/handler"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/mongodb/mongodbatlas-cloudformation-resources/util/workflow"
+)
+
+type state struct {
+	Created  []string
+	Polls    int
+	Failures int
+}
+
+// recorder builds a workflow of two steps: "create" completes at once, "wait" completes after two callbacks
+// unless failWith is set
+type recorder struct {
+	failWith   error
+	rolledBack []string
+}
+
+func (r *recorder) workflow() *workflow.Workflow[state] {
+	return &workflow.Workflow[state]{
+		Steps: []workflow.Step[state]{
+			{
+				Name: "create",
+				Run: func(s *state) (bool, error) {
+					s.Created = append(s.Created, "object")
+					return true, nil
+				},
+				Rollback: func(s *state) error {
+					r.rolledBack = append(r.rolledBack, "create")
+					return nil
+				},
+			},
+			{
+				Name: "wait",
+				Run: func(s *state) (bool, error) {
+					if r.failWith != nil {
+						s.Failures++
+						return false, r.failWith
+					}
+					s.Polls++
+					return s.Polls == 2, nil
+				},
+				Rollback: func(s *state) error {
+					r.rolledBack = append(r.rolledBack, "wait")
+					return errors.New("rollback failed")
+				},
+			},
+		},
+	}
+}
+
+func TestRunSavesStateBetweenCallbacks(t *testing.T) {
+	r := &recorder{}
+	w := r.workflow()
+
+	pe := w.Run(map[string]interface{}{}, nil)
+	if pe == nil || pe.OperationStatus != handler.InProgress {
+		t.Fatalf("first callback: %+v", pe)
+	}
+	if pe.CallbackContext["Step"] != "wait" || pe.CallbackDelaySeconds != workflow.DefaultCallbackDelaySeconds {
+		t.Fatalf("first callback context: %+v", pe)
+	}
+
+	if pe = w.Run(pe.CallbackContext, nil); pe != nil {
+		t.Fatalf("second callback: %+v", pe)
+	}
+	if len(r.rolledBack) != 0 {
+		t.Errorf("rolled back %v", r.rolledBack)
+	}
+}
+
+func TestRunRetriesThenRollsBack(t *testing.T) {
+	r := &recorder{failWith: errors.New("timeout")}
+	w := r.workflow()
+
+	pe := w.Run(nil, nil)
+	for i := 1; i < workflow.DefaultMaxAttempts; i++ {
+		if pe == nil || pe.OperationStatus != handler.InProgress {
+			t.Fatalf("attempt %d: %+v", i, pe)
+		}
+		pe = w.Run(pe.CallbackContext, nil)
+	}
+	if pe == nil || pe.OperationStatus != handler.Failed || pe.HandlerErrorCode != cloudformation.HandlerErrorCodeGeneralServiceException {
+		t.Fatalf("last attempt: %+v", pe)
+	}
+	if want := []string{"wait", "create"}; !reflect.DeepEqual(r.rolledBack, want) {
+		t.Errorf("rolled back %v, want %v", r.rolledBack, want)
+	}
+}
+
+func TestRunFailsAtOnceOnFailure(t *testing.T) {
+	r := &recorder{failWith: workflow.Fail(cloudformation.HandlerErrorCodeAlreadyExists, errors.New("exists"))}
+	w := r.workflow()
+
+	pe := w.Run(nil, nil)
+	if pe == nil || pe.OperationStatus != handler.Failed || pe.HandlerErrorCode != cloudformation.HandlerErrorCodeAlreadyExists {
+		t.Fatalf("got %+v", pe)
+	}
+	if len(r.rolledBack) != 2 {
+		t.Errorf("rolled back %v", r.rolledBack)
+	}
+}
+
+func TestRunWaitsForRollback(t *testing.T) {
+	var rolledBack []string
+	w := &workflow.Workflow[state]{
+		Steps: []workflow.Step[state]{
+			{
+				Name: "create",
+				Run: func(s *state) (bool, error) {
+					return true, nil
+				},
+				Rollback: func(s *state) error {
+					rolledBack = append(rolledBack, "create")
+					return errors.New("still in use")
+				},
+			},
+			{
+				Name: "wait",
+				Run: func(s *state) (bool, error) {
+					return false, workflow.Fail(cloudformation.HandlerErrorCodeInvalidRequest, errors.New("invalid"))
+				},
+				Rollback: func(s *state) error {
+					rolledBack = append(rolledBack, "wait")
+					return nil
+				},
+				WaitRollback: func(s *state) (bool, error) {
+					s.Polls++
+					return s.Polls == 2, nil
+				},
+			},
+		},
+	}
+
+	pe := w.Run(nil, nil)
+	if pe == nil || pe.OperationStatus != handler.InProgress {
+		t.Fatalf("first callback: %+v", pe)
+	}
+	if want := []string{"wait"}; !reflect.DeepEqual(rolledBack, want) {
+		t.Fatalf("rolled back %v, want %v", rolledBack, want)
+	}
+
+	pe = w.Run(pe.CallbackContext, nil)
+	if pe == nil || pe.OperationStatus != handler.Failed || pe.HandlerErrorCode != cloudformation.HandlerErrorCodeInvalidRequest {
+		t.Fatalf("second callback: %+v", pe)
+	}
+	if want := "Error in step wait after 1 attempts: invalid. Rollback failed, create: still in use"; pe.Message != want {
+		t.Errorf("message %q, want %q", pe.Message, want)
+	}
+	if want := []string{"wait", "create"}; !reflect.DeepEqual(rolledBack, want) {
+		t.Errorf("rolled back %v, want %v", rolledBack, want)
+	}
+}
+
+func TestRunUnknownStep(t *testing.T) {
+	r := &recorder{}
+	pe := r.workflow().Run(map[string]interface{}{"Step": "gone"}, nil)
+	if pe == nil || pe.OperationStatus != handler.Failed {
+		t.Fatalf("got %+v", pe)
+	}
+}
+
+func TestEventError(t *testing.T) {
+	var failure *workflow.Failure
+	invalid := &handler.ProgressEvent{Message: "bad", HandlerErrorCode: cloudformation.HandlerErrorCodeInvalidRequest}
+	if err := workflow.EventError(invalid); !errors.As(err, &failure) || failure.Code != cloudformation.HandlerErrorCodeInvalidRequest {
+		t.Errorf("invalid request: %v", err)
+	}
+	internal := &handler.ProgressEvent{Message: "oops", HandlerErrorCode: cloudformation.HandlerErrorCodeServiceInternalError}
+	if err := workflow.EventError(internal); errors.As(err, &failure) || err.Error() != "oops" {
+		t.Errorf("internal error: %v", err)
+	}
+}